@@ -27,16 +27,19 @@ func internalRun(argv []string, uiDriver app.UI) error {
 
 	switch args[0] {
 	case "run":
-		if len(args) < 2 {
-			return fmt.Errorf("usage: %s run <taskId>", app.AppName)
-		}
-		return app.RunTaskByID(uiDriver, args[1])
+		return app.RunTask(uiDriver, args[1:])
 	case "list":
 		return app.ListTasksWithWriter(os.Stdout)
 	case "plugins":
-		return app.ListPluginsWithWriter(os.Stdout)
+		return app.ListPlugins(args[1:], os.Stdout)
 	case "import":
-		return app.ImportSpec(args[1:])
+		return app.ImportSpec(args[1:], os.Stdin, os.Stdout)
+	case "new":
+		return app.New(uiDriver, args[1:], os.Stdout)
+	case "specs":
+		return app.Specs(args[1:], os.Stdin, os.Stdout)
+	case "trust":
+		return app.Trust(args[1:], os.Stdin, os.Stdout)
 	case "help", "-h", "--help":
 		printUsage()
 		return nil
@@ -50,9 +53,12 @@ func printUsage() {
 
 Usage:
   %s            Start interactive TUI
-  %s run <id>   Run task by id (plugin:task)
+  %s run <id>   Run task by id (plugin:task) [--arg name=value]... [--env KEY=VAL]... [--no-input] [--print-env]
   %s list       List tasks
-  %s plugins    List discovered plugins
-  %s import     Import a JSON spec
-`, app.AppName, app.AppName, app.AppName, app.AppName, app.AppName, app.AppName)
+  %s plugins    List discovered plugins [--verify] [--explain [--json]]
+  %s import     Import JSON specs from files, dirs or URLs [--local|--global] [--yes]
+  %s new        Create a spec or plugin skeleton (plugin), or add a task to a spec (task)
+  %s specs      Manage stored specs: list, show, edit, rm, mv, export, update, migrate
+  %s trust      Trust the current repo's local plugins [--yes] [--revoke]
+`, app.AppName, app.AppName, app.AppName, app.AppName, app.AppName, app.AppName, app.AppName, app.AppName, app.AppName)
 }
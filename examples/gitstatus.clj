@@ -19,21 +19,49 @@
        sort
        vec))
 
+(defn list-branches [project]
+  (let [path (str (:projects-dir config) fs/file-separator project)]
+    (if (fs/directory? path)
+      (->> (p/shell {:dir path :out :string} "git branch --format=%(refname:short)")
+           :out
+           clojure.string/split-lines
+           (remove clojure.string/blank?)
+           vec)
+      [])))
+
+(def project-input
+  {:name "project"
+   :type "enum"
+   :required true
+   :prompt "Project"
+   :choicesFrom "project"})
+
 (defn manifest []
-  (let [choices (if (fs/exists? (:projects-dir config))
-                  (list-projects (:projects-dir config))
-                  [])]
-    {:schemaVersion 1
-     :plugin (:plugin config)
-     :tasks [{:name "gst"
-              :title "Git status"
-              :group "Git"
-              :description "Show git status for a project"
-              :inputs [{:name "project"
-                        :type "enum"
-                        :required true
-                        :prompt "Project"
-                        :choices choices}]}]}))
+  {:schemaVersion 1
+   :plugin (:plugin config)
+   :tasks [{:name "gst"
+            :title "Git status"
+            :group "Git"
+            :description "Show git status for a project"
+            :inputs [project-input]}
+           {:name "gco"
+            :title "Git checkout"
+            :group "Git"
+            :description "Checkout a branch of a project"
+            :inputs [project-input
+                     {:name "branch"
+                      :type "enum"
+                      :required true
+                      :prompt "Branch"
+                      :choicesFrom "branch"}]}]})
+
+(defn choices [source args]
+  (case source
+    "project" (if (fs/exists? (:projects-dir config))
+                (list-projects (:projects-dir config))
+                [])
+    "branch" (list-branches (:project args))
+    []))
 
 (defn read-json-stdin []
   (let [raw (slurp *in*)]
@@ -56,18 +84,22 @@
 
 (defn usage []
   (binding [*out* *err*]
-    (println "usage: plugin describe|run <task>"))
+    (println "usage: plugin describe|run <task>|choices <task> <source>"))
   (System/exit 1))
 
 (defn -main [& args]
   (let [cmd (first args)]
     (case cmd
       "describe" (println (json/generate-string (manifest)))
+      "choices" (let [source (nth args 2 nil)
+                      payload (read-json-stdin)]
+                  (println (json/generate-string (choices source (get payload :args {})))))
       "run" (let [task (second args)
                   payload (read-json-stdin)
                   task-args (get payload :args {})]
               (case task
                 "gst" (run-command "git status" task-args)
+                "gco" (run-command (str "git checkout " (:branch task-args)) task-args)
                 (do (binding [*out* *err*]
                       (println (str "unknown task: " task)))
                     (System/exit 1))))
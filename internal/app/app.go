@@ -1,7 +1,9 @@
 package app
 
 import (
+	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"os"
@@ -30,20 +32,21 @@ func RunInteractive(uiDriver UI) error {
 	}
 	state := SelectionState{}
 	lastArgs := make(map[string]map[string]any)
-	tasks, err := refreshTasks(uiDriver, repoRoot)
+	tasks, diagnostics, err := refreshTasks(uiDriver, repoRoot)
 	if err != nil {
 		return err
 	}
-	return runInteractiveLoop(uiDriver, repoRoot, cwd, tasks, state, lastArgs)
+	return runInteractiveLoop(uiDriver, repoRoot, cwd, tasks, diagnostics, state, lastArgs)
 }
 
-func runInteractiveLoop(uiDriver UI, repoRoot, cwd string, tasks []core.TaskRecord, state SelectionState, lastArgs map[string]map[string]any) error {
+func runInteractiveLoop(uiDriver UI, repoRoot, cwd string, tasks []core.TaskRecord, diagnostics core.Diagnostics, state SelectionState, lastArgs map[string]map[string]any) error {
 	for {
-		selected, updatedTasks, nextState, err := selectTaskWithRefresh(uiDriver, repoRoot, tasks, state)
+		selected, updatedTasks, updatedDiagnostics, nextState, err := selectTaskWithRefresh(uiDriver, repoRoot, tasks, diagnostics, state)
 		if err != nil {
 			return err
 		}
 		tasks = updatedTasks
+		diagnostics = updatedDiagnostics
 		state = nextState
 		taskID, args, err := runSelectedTask(uiDriver, selected, repoRoot, cwd, lastArgs)
 		if errors.Is(err, ErrUserCanceled) {
@@ -58,28 +61,38 @@ func runInteractiveLoop(uiDriver UI, repoRoot, cwd string, tasks []core.TaskReco
 	}
 }
 
-func selectTaskWithRefresh(uiDriver UI, repoRoot string, tasks []core.TaskRecord, state SelectionState) (core.TaskRecord, []core.TaskRecord, SelectionState, error) {
+func selectTaskWithRefresh(uiDriver UI, repoRoot string, tasks []core.TaskRecord, diagnostics core.Diagnostics, state SelectionState) (core.TaskRecord, []core.TaskRecord, core.Diagnostics, SelectionState, error) {
 	for {
-		selected, nextState, err := uiDriver.SelectTask(tasks, state)
+		selected, nextState, err := uiDriver.SelectTask(tasks, state, diagnostics)
 		if errors.Is(err, ErrRefresh) {
-			updatedTasks, loadErr := refreshTasks(uiDriver, repoRoot)
+			updatedTasks, updatedDiagnostics, loadErr := refreshTasks(uiDriver, repoRoot)
 			if loadErr != nil {
-				return core.TaskRecord{}, tasks, nextState, loadErr
+				return core.TaskRecord{}, tasks, diagnostics, nextState, loadErr
 			}
 			tasks = updatedTasks
+			diagnostics = updatedDiagnostics
 			state = nextState
+			// Show the banner again: a refresh may find new problems.
+			state.DiagnosticsDismissed = false
 			continue
 		}
 		if err != nil {
-			return core.TaskRecord{}, tasks, nextState, err
+			return core.TaskRecord{}, tasks, diagnostics, nextState, err
 		}
-		return selected, tasks, nextState, nil
+		return selected, tasks, diagnostics, nextState, nil
 	}
 }
 
 func runSelectedTask(uiDriver UI, selected core.TaskRecord, repoRoot, cwd string, lastArgs map[string]map[string]any) (string, map[string]any, error) {
-	taskID := core.TaskID(selected.PluginID, selected.Task.Name)
-	args, err := uiDriver.PromptInputs(selected.Task.Inputs, lastArgs[taskID])
+	taskID := selected.ID()
+	repoRoot, cwd = taskRoots(selected, repoRoot, cwd)
+	preset, err := core.PresetArgs(selected, nil, repoRoot, cwd)
+	if err != nil {
+		return "", nil, err
+	}
+	ctx := promptContext(selected, repoRoot, cwd, nil)
+	ctx.Preset = preset
+	args, err := uiDriver.PromptInputs(selected.Task.Inputs, lastArgs[taskID], ctx)
 	if err != nil {
 		return "", nil, err
 	}
@@ -91,10 +104,24 @@ func runSelectedTask(uiDriver UI, selected core.TaskRecord, repoRoot, cwd string
 	if err := uiDriver.WaitForEnter(); err != nil {
 		return "", nil, err
 	}
-	return taskID, args, nil
+	return taskID, core.WithoutSecrets(selected.Task.Inputs, args), nil
+}
+
+func promptContext(task core.TaskRecord, repoRoot, cwd string, env map[string]string) PromptContext {
+	return PromptContext{
+		RepoRoot: repoRoot,
+		Cwd:      cwd,
+		Choices: func(input core.InputSpec, values map[string]any) ([]string, error) {
+			return core.ResolveChoices(task, input, repoRoot, cwd, values, env)
+		},
+	}
 }
 
 func RunTaskByID(uiDriver UI, id string) error {
+	return runTaskByID(uiDriver, id, runOptions{})
+}
+
+func runTaskByID(uiDriver UI, id string, opts runOptions) error {
 	cwd, err := getwd()
 	if err != nil {
 		return err
@@ -104,37 +131,110 @@ func RunTaskByID(uiDriver UI, id string) error {
 		return err
 	}
 	for _, task := range tasks {
-		if core.TaskID(task.PluginID, task.Task.Name) == id {
-			args, err := uiDriver.PromptInputs(task.Task.Inputs, nil)
+		if task.ID() == id {
+			repoRoot, cwd := taskRoots(task, repoRoot, cwd)
+			provided, err := core.ParseArgs(task.Task.Inputs, opts.args, repoRoot, cwd)
+			if err != nil {
+				return err
+			}
+			preset, err := core.PresetArgs(task, provided, repoRoot, cwd)
+			if err != nil {
+				return err
+			}
+			if opts.printEnv {
+				return printTaskEnv(os.Stdout, task, repoRoot, cwd, opts.env, preset)
+			}
+			var args map[string]any
+			if opts.noInput {
+				for name, value := range preset {
+					provided[name] = value
+				}
+				args, err = core.ResolveArgs(task.Task.Inputs, provided, repoRoot, cwd)
+			} else {
+				ctx := promptContext(task, repoRoot, cwd, opts.env)
+				ctx.Preset = preset
+				args, err = uiDriver.PromptInputs(task.Task.Inputs, provided, ctx)
+			}
 			if err != nil {
 				return err
 			}
-			return core.RunPluginTask(task, repoRoot, cwd, args)
+			return core.RunPluginTaskWithEnv(task, repoRoot, cwd, args, opts.env)
 		}
 	}
 	return fmt.Errorf("task not found: %s", id)
 }
 
+// printTaskEnv writes the environment a task would receive, one KEY=VAL per
+// line, with credential-looking values masked. Inputs preset by the repo's
+// overrides are listed first as comments, since they are never asked.
+func printTaskEnv(writer io.Writer, task core.TaskRecord, repoRoot, cwd string, overrides map[string]string, preset map[string]any) error {
+	env, err := core.TaskEnv(task, repoRoot, cwd, overrides)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(preset))
+	for name := range preset {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value, _ := json.Marshal(preset[name])
+		fmt.Fprintf(writer, "# preset %s=%s\n", name, value)
+	}
+	for _, entry := range core.MaskEnv(env) {
+		fmt.Fprintln(writer, entry)
+	}
+	return nil
+}
+
 func ListTasksWithWriter(writer io.Writer) error {
 	_, tasks, err := currentRepoAndTasks()
 	if err != nil {
 		return err
 	}
 	for _, task := range tasks {
-		fmt.Fprintf(writer, "%s\t%s\t%s\n", core.TaskID(task.PluginID, task.Task.Name), task.Task.Title, task.Task.Description)
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", task.ID(), task.Task.Title, task.Task.Description)
 	}
 	return nil
 }
 
+func ListPlugins(args []string, writer io.Writer) error {
+	fs := flag.NewFlagSet("plugins", flag.ContinueOnError)
+	var verify bool
+	var explain bool
+	var asJSON bool
+	fs.BoolVar(&verify, "verify", false, "compare local plugins with the last trusted snapshot")
+	fs.BoolVar(&explain, "explain", false, "show every plugin candidate and which one was used")
+	fs.BoolVar(&asJSON, "json", false, "print --explain output as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if asJSON && !explain {
+		return errors.New("--json requires --explain")
+	}
+	if verify {
+		return VerifyPluginsWithWriter(writer)
+	}
+	if explain {
+		return ExplainPluginsWithWriter(writer, asJSON)
+	}
+	return ListPluginsWithWriter(writer)
+}
+
 func ListPluginsWithWriter(writer io.Writer) error {
 	repoRoot, err := currentRepoRoot()
 	if err != nil {
 		return err
 	}
-	plugins, err := core.LoadPlugins(repoRoot)
+	plugins, diagnostics, err := core.LoadPlugins(repoRoot)
+	if err != nil {
+		return err
+	}
+	untrusted, err := untrustedDiagnostics(repoRoot)
 	if err != nil {
 		return err
 	}
+	append(untrusted, diagnostics...).Print(os.Stderr)
 	if len(plugins) == 0 {
 		fmt.Fprintln(writer, "no plugins found")
 		return nil
@@ -148,31 +248,90 @@ func ListPluginsWithWriter(writer io.Writer) error {
 	return nil
 }
 
-func refreshTasks(uiDriver UI, repoRoot string) ([]core.TaskRecord, error) {
+func refreshTasks(uiDriver UI, repoRoot string) ([]core.TaskRecord, core.Diagnostics, error) {
 	uiDriver.ClearScreen()
 	uiDriver.RenderLoading("Loading tasks...")
-	tasks, err := loadTasks(repoRoot)
+	tasks, diagnostics, err := loadTasks(repoRoot)
 	uiDriver.ClearScreen()
-	return tasks, err
+	return tasks, diagnostics, err
 }
 
-func loadTasks(repoRoot string) ([]core.TaskRecord, error) {
-	plugins, err := core.LoadPlugins(repoRoot)
+// loadTasks returns the sorted tasks of repoRoot, or of every workspace
+// repo in workspace mode, with the repos' overrides applied, and
+// diagnostics about local plugins that were not run, plugins and specs that
+// could not be loaded, and overrides that matched nothing.
+func loadTasks(repoRoot string) ([]core.TaskRecord, core.Diagnostics, error) {
+	members, diagnostics, err := core.WorkspaceRepos(repoRoot)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	tasks := core.BuildTasks(plugins)
+	var tasks []core.TaskRecord
+	var loadDiagnostics core.Diagnostics
+	var untrusted int
+	if len(members) == 0 {
+		tasks, loadDiagnostics, untrusted, err = loadRepoTasks(repoRoot, true)
+	} else {
+		tasks, loadDiagnostics, untrusted, err = loadWorkspaceTasks(repoRoot, members)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	diagnostics = append(diagnostics, loadDiagnostics...)
 	if len(tasks) == 0 {
-		return nil, fmt.Errorf("no tasks found")
+		if untrusted > 0 {
+			return nil, diagnostics, fmt.Errorf("no tasks found (%d untrusted local plugins, run `%s trust` to allow them)", untrusted, AppName)
+		}
+		return nil, diagnostics, fmt.Errorf("no tasks found")
 	}
 	sortTasks(tasks)
-	return tasks, nil
+	return tasks, diagnostics, nil
+}
+
+// loadRepoTasks returns the tasks of one repo, with global ones if global
+// is set, with its overrides applied, its diagnostics and the number of
+// untrusted local plugins.
+func loadRepoTasks(repoRoot string, global bool) ([]core.TaskRecord, core.Diagnostics, int, error) {
+	load := core.LoadLocalPlugins
+	if global {
+		load = core.LoadPlugins
+	}
+	plugins, loadDiagnostics, err := load(repoRoot)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	untrusted, err := untrustedDiagnostics(repoRoot)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	overrides, err := core.LoadOverrides(repoRoot)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	tasks, overrideDiagnostics := core.ApplyOverrides(core.BuildTasks(plugins), overrides)
+	return tasks, append(append(untrusted, loadDiagnostics...), overrideDiagnostics...), len(untrusted), nil
+}
+
+func untrustedDiagnostics(repoRoot string) (core.Diagnostics, error) {
+	var diagnostics core.Diagnostics
+	for depth, root := range core.RepoLevels(repoRoot) {
+		untrusted, err := core.UntrustedPlugins(root)
+		if err != nil {
+			return nil, err
+		}
+		// Outer levels are trusted on their own, from their directory.
+		hint := fmt.Sprintf("run `%s trust` to allow it", AppName)
+		if depth > 0 {
+			hint = fmt.Sprintf("run `%s trust` in %s to allow it", AppName, root)
+		}
+		for _, plugin := range untrusted {
+			diagnostics.Warn(plugin.Path, fmt.Sprintf("untrusted plugin skipped (%s): would run %s; %s", plugin.Reason, plugin.Command, hint), nil)
+		}
+	}
+	return diagnostics, nil
 }
 
 func sortTasks(tasks []core.TaskRecord) {
 	sort.Slice(tasks, func(i, j int) bool {
-		ai := core.TaskID(tasks[i].PluginID, tasks[i].Task.Name)
-		aj := core.TaskID(tasks[j].PluginID, tasks[j].Task.Name)
-		return ai < aj
+		return tasks[i].ID() < tasks[j].ID()
 	})
 }
@@ -2,9 +2,14 @@ package app
 
 import (
 	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
+
+	"github.com/ea2809/automate-me/internal/core"
 )
 
 func TestListTasksOutputs(t *testing.T) {
@@ -19,6 +24,9 @@ func TestListTasksOutputs(t *testing.T) {
   "tasks": [{"name": "t", "title": "Title", "description": "Desc"}]
 }`
 	writeSpecFile(t, specDir, "p.json", spec)
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	trustRepo(t, repo)
 	chdirTo(t, repo)
 
 	var buf bytes.Buffer
@@ -43,6 +51,9 @@ func TestListPluginsOutputs(t *testing.T) {
   "tasks": []
 }`
 	writeSpecFile(t, specDir, "p.json", spec)
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	trustRepo(t, repo)
 	chdirTo(t, repo)
 
 	var buf bytes.Buffer
@@ -68,3 +79,42 @@ func TestListPluginsEmpty(t *testing.T) {
 		t.Fatalf("unexpected output: %s", buf.String())
 	}
 }
+
+func TestListPluginsExplain(t *testing.T) {
+	base := t.TempDir()
+	repo, specDir := createRepoWithLocalSpecsDir(t, base)
+	spec := `{
+  "schemaVersion": 2,
+  "plugin": {"id": "p", "title": "P", "exec": "/bin/echo"},
+  "tasks": [{"name": "t", "title": "t"}, {"name": "t", "title": "again"}]
+}`
+	writeSpecFile(t, specDir, "p.json", spec)
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	trustRepo(t, repo)
+	chdirTo(t, repo)
+
+	var buf bytes.Buffer
+	if err := ListPlugins([]string{"--explain"}, &buf); err != nil {
+		t.Fatal(err)
+	}
+	output := buf.String()
+	if !strings.Contains(output, "p\n  selected\tspec\tlocal\t") || !strings.Contains(output, "duplicate tasks: t") {
+		t.Fatalf("unexpected output: %s", output)
+	}
+
+	buf.Reset()
+	if err := ListPlugins([]string{"--explain", "--json"}, &buf); err != nil {
+		t.Fatal(err)
+	}
+	var explanation core.PluginExplanation
+	if err := json.Unmarshal(buf.Bytes(), &explanation); err != nil {
+		t.Fatalf("invalid JSON %s: %v", buf.String(), err)
+	}
+	if len(explanation.Plugins) != 1 || explanation.Plugins[0].Candidates[0].Status != core.CandidateSelected {
+		t.Fatalf("unexpected explanation: %#v", explanation)
+	}
+	if err := ListPlugins([]string{"--json"}, &buf); err == nil {
+		t.Fatal("expected --json without --explain to fail")
+	}
+}
@@ -13,11 +13,11 @@ type cancelUI struct{}
 
 func (c cancelUI) ClearScreen() {}
 
-func (c cancelUI) SelectTask(tasks []core.TaskRecord, state SelectionState) (core.TaskRecord, SelectionState, error) {
+func (c cancelUI) SelectTask(tasks []core.TaskRecord, state SelectionState, diagnostics core.Diagnostics) (core.TaskRecord, SelectionState, error) {
 	return core.TaskRecord{}, state, ErrUserCanceled
 }
 
-func (c cancelUI) PromptInputs(inputs []core.InputSpec, defaults map[string]any) (map[string]any, error) {
+func (c cancelUI) PromptInputs(inputs []core.InputSpec, defaults map[string]any, ctx PromptContext) (map[string]any, error) {
 	return map[string]any{}, nil
 }
 
@@ -34,14 +34,14 @@ type sequenceUI struct {
 
 func (s *sequenceUI) ClearScreen() {}
 
-func (s *sequenceUI) SelectTask(tasks []core.TaskRecord, state SelectionState) (core.TaskRecord, SelectionState, error) {
+func (s *sequenceUI) SelectTask(tasks []core.TaskRecord, state SelectionState, diagnostics core.Diagnostics) (core.TaskRecord, SelectionState, error) {
 	if s.index >= len(s.argsSequence) {
 		return core.TaskRecord{}, state, ErrUserCanceled
 	}
 	return tasks[0], state, nil
 }
 
-func (s *sequenceUI) PromptInputs(inputs []core.InputSpec, defaults map[string]any) (map[string]any, error) {
+func (s *sequenceUI) PromptInputs(inputs []core.InputSpec, defaults map[string]any, ctx PromptContext) (map[string]any, error) {
 	args := s.argsSequence[s.index]
 	if defaults != nil {
 		for key, value := range defaults {
@@ -84,6 +84,9 @@ func TestRunInteractiveRespectsDefaults(t *testing.T) {
   "tasks": [{"name": "t", "title": "t"}]
 }`
 	writeSpecFile(t, specDir, "p.json", spec)
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	trustRepo(t, repo)
 	chdirTo(t, repo)
 
 	ui := &sequenceUI{
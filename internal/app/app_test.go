@@ -1,9 +1,11 @@
 package app
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/ea2809/automate-me/internal/core"
@@ -13,11 +15,11 @@ type fakeUI struct{}
 
 func (f fakeUI) ClearScreen() {}
 
-func (f fakeUI) SelectTask(tasks []core.TaskRecord, state SelectionState) (core.TaskRecord, SelectionState, error) {
+func (f fakeUI) SelectTask(tasks []core.TaskRecord, state SelectionState, diagnostics core.Diagnostics) (core.TaskRecord, SelectionState, error) {
 	return core.TaskRecord{}, state, ErrUserCanceled
 }
 
-func (f fakeUI) PromptInputs(inputs []core.InputSpec, defaults map[string]any) (map[string]any, error) {
+func (f fakeUI) PromptInputs(inputs []core.InputSpec, defaults map[string]any, ctx PromptContext) (map[string]any, error) {
 	if defaults != nil {
 		return defaults, nil
 	}
@@ -61,6 +63,9 @@ func TestRunTaskByIDExecutes(t *testing.T) {
   "tasks": [{"name": "t", "title": "t"}]
 }`
 	writeSpecFile(t, specDir, "p.json", spec)
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	trustRepo(t, repo)
 	chdirTo(t, repo)
 
 	if err := RunTaskByID(fakeUI{}, "p:t"); err != nil {
@@ -70,3 +75,139 @@ func TestRunTaskByIDExecutes(t *testing.T) {
 		t.Fatalf("expected output file: %v", err)
 	}
 }
+
+func TestRunTaskArgForDynamicMultienum(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping shell script test on windows")
+	}
+	base := t.TempDir()
+	repo, specDir := createRepoWithLocalSpecsDir(t, base)
+	outputFile := filepath.Join(base, "out.txt")
+	script := filepath.Join(base, "run.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho \"$@\" > \"$OUTPUT_FILE\"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("OUTPUT_FILE", outputFile)
+	defer os.Unsetenv("OUTPUT_FILE")
+	spec := `{
+  "schemaVersion": 2,
+  "plugin": {"id": "p", "title": "P", "exec": "` + script + `"},
+  "tasks": [{"name": "t", "title": "t", "args": ["{{join .args.tags \",\"}}"], "inputs": [
+    {"name": "tags", "type": "multienum", "choicesFrom": "tags"}
+  ]}]
+}`
+	writeSpecFile(t, specDir, "p.json", spec)
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	trustRepo(t, repo)
+	chdirTo(t, repo)
+
+	if err := RunTask(fakeUI{}, []string{"p:t", "--no-input", "--arg", "tags=a,b"}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(data)) != "a,b" {
+		t.Fatalf("expected dynamic multienum values, got %q", data)
+	}
+}
+
+func TestRunTaskNoInputMissingRequired(t *testing.T) {
+	base := t.TempDir()
+	repo, specDir := createRepoWithLocalSpecsDir(t, base)
+	spec := `{
+  "schemaVersion": 1,
+  "plugin": {"id": "p", "title": "P", "exec": "/bin/echo"},
+  "tasks": [{"name": "t", "title": "t", "inputs": [
+    {"name": "release", "type": "bool", "default": false},
+    {"name": "version", "type": "string", "required": true, "when": {"release": true}}
+  ]}]
+}`
+	writeSpecFile(t, specDir, "p.json", spec)
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	trustRepo(t, repo)
+	chdirTo(t, repo)
+
+	if err := RunTask(fakeUI{}, []string{"p:t", "--no-input"}); err != nil {
+		t.Fatalf("expected skipped input to pass validation: %v", err)
+	}
+	if err := RunTask(fakeUI{}, []string{"p:t", "--no-input", "--arg", "release=true"}); err == nil {
+		t.Fatal("expected missing version error")
+	}
+}
+
+func TestRunTaskAppliesOverrides(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping shell script test on windows")
+	}
+	base := t.TempDir()
+	repo, specDir := createRepoWithLocalSpecsDir(t, base)
+	outputFile := filepath.Join(base, "out.txt")
+	script := filepath.Join(base, "run.sh")
+	scriptBody := "#!/bin/sh\n" +
+		"cat > \"$OUTPUT_FILE\"\n"
+	if err := os.WriteFile(script, []byte(scriptBody), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("OUTPUT_FILE", outputFile)
+	defer os.Unsetenv("OUTPUT_FILE")
+
+	spec := `{
+  "schemaVersion": 2,
+  "plugin": {"id": "p", "title": "P", "exec": "` + script + `"},
+  "tasks": [
+    {"name": "t", "title": "t", "inputs": [
+      {"name": "release", "type": "bool", "default": false},
+      {"name": "version", "type": "string", "required": true, "when": {"release": true}}
+    ]},
+    {"name": "hidden", "title": "hidden"}
+  ]
+}`
+	writeSpecFile(t, specDir, "p.json", spec)
+	overrides := `{"tasks": {
+  "p:t": {"values": {"release": true, "version": "1.2.0"}},
+  "p:hidden": {"hidden": true}
+}}`
+	if err := os.WriteFile(filepath.Join(repo, testLocalConfigDirName, "overrides.json"), []byte(overrides), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	trustRepo(t, repo)
+	chdirTo(t, repo)
+
+	if err := RunTask(fakeUI{}, []string{"p:t", "--no-input"}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"version":"1.2.0"`) {
+		t.Fatalf("expected preset version in payload, got %s", data)
+	}
+	if err := RunTask(fakeUI{}, []string{"p:t", "--no-input", "--arg", "version=2.0.0"}); err != nil {
+		t.Fatal(err)
+	}
+	data, err = os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"version":"2.0.0"`) {
+		t.Fatalf("expected --arg to win over preset, got %s", data)
+	}
+	if err := RunTask(fakeUI{}, []string{"p:hidden", "--no-input"}); err == nil {
+		t.Fatal("expected hidden task to be not found")
+	}
+	var env bytes.Buffer
+	task := core.TaskRecord{PluginID: "p", Task: core.TaskSpec{Name: "t"}}
+	if err := printTaskEnv(&env, task, repo, repo, nil, map[string]any{"version": "1.2.0"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(env.String(), "# preset version=\"1.2.0\"\n") {
+		t.Fatalf("expected presets listed in --print-env output, got %s", env.String())
+	}
+}
@@ -0,0 +1,68 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ea2809/automate-me/internal/core"
+)
+
+// ExplainPluginsWithWriter prints every plugin candidate of the current
+// repo, grouped by plugin id, with the one that was used and why, followed
+// by the candidates that were skipped and the diagnostics.
+func ExplainPluginsWithWriter(writer io.Writer, asJSON bool) error {
+	repoRoot, err := currentRepoRoot()
+	if err != nil {
+		return err
+	}
+	explanation, err := core.ExplainPlugins(repoRoot)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		data, err := json.MarshalIndent(explanation, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(writer, string(data))
+		return nil
+	}
+	if len(explanation.Plugins) == 0 && len(explanation.Skipped) == 0 && len(explanation.Diagnostics) == 0 {
+		fmt.Fprintln(writer, "no plugins found")
+		return nil
+	}
+	for _, resolution := range explanation.Plugins {
+		fmt.Fprintln(writer, resolution.ID)
+		for _, candidate := range resolution.Candidates {
+			printCandidate(writer, candidate)
+		}
+		if len(resolution.DuplicateTasks) > 0 {
+			fmt.Fprintf(writer, "  duplicate tasks: %s\n", strings.Join(resolution.DuplicateTasks, ", "))
+		}
+	}
+	if len(explanation.Skipped) > 0 {
+		fmt.Fprintln(writer, "skipped")
+		for _, candidate := range explanation.Skipped {
+			printCandidate(writer, candidate)
+		}
+	}
+	if len(explanation.Diagnostics) > 0 {
+		fmt.Fprintln(writer, "diagnostics")
+		for _, diagnostic := range explanation.Diagnostics {
+			fmt.Fprintf(writer, "  %s: %s\n", diagnostic.Severity, diagnostic)
+			for _, line := range core.DetailLines(diagnostic.Detail) {
+				fmt.Fprintf(writer, "    %s\n", line)
+			}
+		}
+	}
+	return nil
+}
+
+func printCandidate(writer io.Writer, candidate core.PluginCandidate) {
+	fmt.Fprintf(writer, "  %s\t%s\t%s\t%s\t%s\n", candidate.Status, candidate.Kind, candidate.Scope, candidate.Path, candidate.Reason)
+	for _, line := range core.DetailLines(candidate.Stderr) {
+		fmt.Fprintf(writer, "    stderr: %s\n", line)
+	}
+}
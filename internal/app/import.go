@@ -1,31 +1,34 @@
 package app
 
 import (
+	"bufio"
 	"errors"
 	"flag"
 	"fmt"
-	"path/filepath"
+	"io"
 
 	"github.com/ea2809/automate-me/internal/core"
 )
 
-func ImportSpec(args []string) error {
+func ImportSpec(args []string, in io.Reader, writer io.Writer) error {
 	fs := flag.NewFlagSet("import", flag.ContinueOnError)
 	var useGlobal bool
 	var useLocal bool
+	var yes bool
 	fs.BoolVar(&useGlobal, "global", false, "store in global spec dir")
 	fs.BoolVar(&useLocal, "local", false, "store in repo spec dir")
-	if err := fs.Parse(args); err != nil {
+	fs.BoolVar(&yes, "yes", false, "replace specs with the same id without asking")
+	paths, err := parseInterleaved(fs, args)
+	if err != nil {
 		return err
 	}
 	if useGlobal && useLocal {
 		return errors.New("use only one of --global or --local")
 	}
-	if fs.NArg() < 1 {
-		return errors.New("usage: automate-me import <spec.json> [--local|--global]")
+	if len(paths) < 1 {
+		return errors.New("usage: automate-me import <spec.json|spec.yaml|spec.toml|dir|url>... [--local|--global] [--yes]")
 	}
 
-	path := fs.Arg(0)
 	repoRoot, err := currentRepoRoot()
 	if err != nil {
 		return err
@@ -34,10 +37,51 @@ func ImportSpec(args []string) error {
 	if useLocal || (!useGlobal && repoRoot != "") {
 		scope = core.ScopeLocal
 	}
-	storedPath, err := core.ImportSpecFile(path, repoRoot, scope)
+	sources, err := core.ExpandSpecSources(paths)
 	if err != nil {
 		return err
 	}
-	fmt.Printf("imported %s -> %s\n", filepath.Base(path), storedPath)
+	reader := bufio.NewReader(in)
+	for _, source := range sources {
+		plan, err := core.PlanSpecImport(source, repoRoot, scope)
+		if err != nil {
+			return err
+		}
+		if plan.Collision() && !yes {
+			previous := plan.ExistingSource
+			if previous == "" {
+				previous = "an unknown source"
+			}
+			fmt.Fprintf(writer, "spec id %s already imported from %s (%s)\n", plan.ID, previous, plan.ExistingPath)
+			ok, err := confirm(reader, writer, fmt.Sprintf("Replace it with %s? [y/N] ", source))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				fmt.Fprintf(writer, "skipped %s\n", source)
+				continue
+			}
+		}
+		if err := plan.Write(); err != nil {
+			return err
+		}
+		fmt.Fprintf(writer, "imported %s -> %s\n", source, plan.DestPath)
+	}
 	return nil
 }
+
+// parseInterleaved parses flags given before, between or after positional
+// arguments and returns the positional ones.
+func parseInterleaved(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
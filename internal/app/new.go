@@ -0,0 +1,264 @@
+package app
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ea2809/automate-me/internal/core"
+)
+
+const newUsage = "usage: automate-me new plugin|task [--local|--global]"
+
+// namePattern limits plugin ids, task and input names to what is safe in
+// file names, task ids and generated code.
+const namePattern = `^[A-Za-z0-9][A-Za-z0-9_.-]*$`
+
+const (
+	kindDirect   = "direct spec"
+	kindProtocol = "protocol spec"
+	kindPlugin   = "plugin skeleton"
+)
+
+var inputTypes = []string{"string", "int", "float", "bool", "path", "enum", "multienum", "text", "duration", "date", "list", "kv"}
+
+// New runs the authoring wizard: `new plugin` writes a spec or scaffolds a
+// protocol plugin, `new task` adds a task to a stored spec. Every question
+// is asked through the UI's input prompts.
+func New(uiDriver UI, args []string, writer io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(newUsage)
+	}
+	fs := flag.NewFlagSet("new "+args[0], flag.ContinueOnError)
+	var useGlobal bool
+	var useLocal bool
+	fs.BoolVar(&useGlobal, "global", false, "use the global spec dir")
+	fs.BoolVar(&useLocal, "local", false, "use the repo spec dir")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if useGlobal && useLocal {
+		return errors.New("use only one of --global or --local")
+	}
+	repoRoot, err := currentRepoRoot()
+	if err != nil {
+		return err
+	}
+	scope := core.ScopeGlobal
+	if useLocal || (!useGlobal && repoRoot != "") {
+		scope = core.ScopeLocal
+	}
+	switch args[0] {
+	case "plugin":
+		return newPlugin(uiDriver, repoRoot, scope, writer)
+	case "task":
+		return newTask(uiDriver, repoRoot, scope, writer)
+	default:
+		return fmt.Errorf("unknown new command: %s\n%s", args[0], newUsage)
+	}
+}
+
+func newPlugin(uiDriver UI, repoRoot string, scope core.PluginScope, writer io.Writer) error {
+	kinds := []string{kindDirect, kindProtocol}
+	if scope == core.ScopeLocal {
+		kinds = append(kinds, kindPlugin)
+	}
+	values, err := uiDriver.PromptInputs([]core.InputSpec{
+		{Name: "id", Type: "string", Required: true, Prompt: "Plugin ID", Pattern: namePattern},
+		{Name: "title", Type: "string", Prompt: "Plugin title"},
+		{Name: "kind", Type: "enum", Required: true, Prompt: "Kind", Choices: kinds, Default: kindDirect},
+		{Name: "exec", Type: "string", Prompt: "Command run by tasks without their own (plugin.exec)", When: map[string]any{"kind": kindDirect}},
+		{Name: "protocolExec", Type: "string", Required: true, Prompt: "Protocol plugin executable", When: map[string]any{"kind": kindProtocol}},
+		{Name: "language", Type: "enum", Required: true, Prompt: "Language", Choices: core.ScaffoldLanguages, Default: "sh", When: map[string]any{"kind": kindPlugin}},
+	}, nil, PromptContext{RepoRoot: repoRoot})
+	if err != nil {
+		return err
+	}
+	kind := stringValue(values, "kind")
+	manifest := core.Manifest{
+		SchemaVersion: core.CurrentSchemaVersion,
+		Plugin: core.PluginInfo{
+			ID:    stringValue(values, "id"),
+			Title: stringValue(values, "title"),
+		},
+	}
+	if manifest.Plugin.Title == "" {
+		manifest.Plugin.Title = manifest.Plugin.ID
+	}
+	switch kind {
+	case kindDirect:
+		manifest.Plugin.Exec = stringValue(values, "exec")
+	case kindProtocol:
+		manifest.Plugin.Exec = stringValue(values, "protocolExec")
+		manifest.Plugin.ExecMode = "protocol"
+	}
+	for {
+		task, err := promptTask(uiDriver, repoRoot, kind == kindDirect)
+		if err != nil {
+			return err
+		}
+		manifest.Tasks = append(manifest.Tasks, task)
+		more, err := promptConfirm(uiDriver, repoRoot, "Add another task?")
+		if err != nil {
+			return err
+		}
+		if !more {
+			break
+		}
+	}
+	if kind == kindPlugin {
+		files, err := core.ScaffoldPlugin(manifest, stringValue(values, "language"), repoRoot)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			fmt.Fprintf(writer, "created %s\n", file)
+		}
+		fmt.Fprintf(writer, "run `%s trust` to allow the new plugin\n", AppName)
+		return nil
+	}
+	path, err := core.CreateSpec(manifest, repoRoot, scope)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(writer, "created %s\n", path)
+	return nil
+}
+
+func newTask(uiDriver UI, repoRoot string, scope core.PluginScope, writer io.Writer) error {
+	specs, diagnostics, err := core.ListStoredSpecs(repoRoot)
+	if err != nil {
+		return err
+	}
+	diagnostics.Print(os.Stderr)
+	var ids []string
+	byID := make(map[string]core.StoredSpec)
+	for _, spec := range specs {
+		if spec.Scope == scope {
+			ids = append(ids, spec.ID)
+			byID[spec.ID] = spec
+		}
+	}
+	if len(ids) == 0 {
+		return fmt.Errorf("no %s specs; create one with `%s new plugin`", scope, AppName)
+	}
+	values, err := uiDriver.PromptInputs([]core.InputSpec{
+		{Name: "spec", Type: "enum", Required: true, Prompt: "Add task to", Choices: ids, Default: ids[0]},
+	}, nil, PromptContext{RepoRoot: repoRoot})
+	if err != nil {
+		return err
+	}
+	spec, ok := byID[stringValue(values, "spec")]
+	if !ok {
+		return fmt.Errorf("no %s spec with id %s", scope, stringValue(values, "spec"))
+	}
+	direct := !strings.EqualFold(spec.Manifest.Plugin.ExecMode, "protocol")
+	task, err := promptTask(uiDriver, repoRoot, direct)
+	if err != nil {
+		return err
+	}
+	for _, existing := range spec.Manifest.Tasks {
+		if existing.Name == task.Name {
+			return fmt.Errorf("spec %s already has a task %s", spec.ID, task.Name)
+		}
+	}
+	spec.Manifest.Tasks = append(spec.Manifest.Tasks, task)
+	data, err := core.ExportSpecFormat(spec.Manifest, core.ManifestFormat(spec.Path))
+	if err != nil {
+		return err
+	}
+	if err := core.SaveStoredSpec(spec, data); err != nil {
+		return err
+	}
+	fmt.Fprintf(writer, "added %s to %s\n", core.TaskID(spec.ID, task.Name), spec.Path)
+	return nil
+}
+
+// promptTask asks for one task and its inputs. Direct tasks may set their
+// own command, split on spaces.
+func promptTask(uiDriver UI, repoRoot string, direct bool) (core.TaskSpec, error) {
+	questions := []core.InputSpec{
+		{Name: "name", Type: "string", Required: true, Prompt: "Task name", Pattern: namePattern},
+		{Name: "title", Type: "string", Prompt: "Task title"},
+		{Name: "group", Type: "string", Prompt: "Group"},
+		{Name: "description", Type: "string", Prompt: "Description"},
+	}
+	if direct {
+		questions = append(questions, core.InputSpec{Name: "command", Type: "string", Prompt: "Command (blank for plugin.exec; {{.args.name}} inserts an input)"})
+	}
+	questions = append(questions, core.InputSpec{Name: "inputs", Type: "bool", Prompt: "Add inputs?", Default: false})
+	values, err := uiDriver.PromptInputs(questions, nil, PromptContext{RepoRoot: repoRoot})
+	if err != nil {
+		return core.TaskSpec{}, err
+	}
+	task := core.TaskSpec{
+		Name:        stringValue(values, "name"),
+		Title:       stringValue(values, "title"),
+		Group:       stringValue(values, "group"),
+		Description: stringValue(values, "description"),
+		Command:     strings.Fields(stringValue(values, "command")),
+	}
+	if task.Title == "" {
+		task.Title = task.Name
+	}
+	more, _ := values["inputs"].(bool)
+	for more {
+		input, err := promptInput(uiDriver, repoRoot)
+		if err != nil {
+			return core.TaskSpec{}, err
+		}
+		task.Inputs = append(task.Inputs, input)
+		more, err = promptConfirm(uiDriver, repoRoot, "Add another input?")
+		if err != nil {
+			return core.TaskSpec{}, err
+		}
+	}
+	return task, nil
+}
+
+func promptInput(uiDriver UI, repoRoot string) (core.InputSpec, error) {
+	withChoices := map[string]any{"type": []any{"enum", "multienum"}}
+	values, err := uiDriver.PromptInputs([]core.InputSpec{
+		{Name: "name", Type: "string", Required: true, Prompt: "Input name", Pattern: namePattern},
+		{Name: "type", Type: "enum", Required: true, Prompt: "Input type", Choices: inputTypes, Default: "string"},
+		{Name: "choices", Type: "list", Required: true, Prompt: "Choices", When: withChoices},
+		{Name: "prompt", Type: "string", Prompt: "Prompt"},
+		{Name: "required", Type: "bool", Prompt: "Required?", Default: false},
+		{Name: "default", Type: "string", Prompt: "Default"},
+	}, nil, PromptContext{RepoRoot: repoRoot})
+	if err != nil {
+		return core.InputSpec{}, err
+	}
+	input := core.InputSpec{
+		Name:   stringValue(values, "name"),
+		Type:   stringValue(values, "type"),
+		Prompt: stringValue(values, "prompt"),
+	}
+	input.Required, _ = values["required"].(bool)
+	if choices, ok := values["choices"].([]string); ok {
+		input.Choices = choices
+	}
+	if def := stringValue(values, "default"); def != "" {
+		input.Default = def
+	}
+	return input, nil
+}
+
+func promptConfirm(uiDriver UI, repoRoot, prompt string) (bool, error) {
+	values, err := uiDriver.PromptInputs([]core.InputSpec{
+		{Name: "confirm", Type: "bool", Prompt: prompt, Default: false},
+	}, nil, PromptContext{RepoRoot: repoRoot})
+	if err != nil {
+		return false, err
+	}
+	ok, _ := values["confirm"].(bool)
+	return ok, nil
+}
+
+func stringValue(values map[string]any, name string) string {
+	value, _ := values[name].(string)
+	return strings.TrimSpace(value)
+}
@@ -0,0 +1,117 @@
+package app
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/ea2809/automate-me/internal/core"
+)
+
+// answersUI answers each PromptInputs call with the next map of values.
+type answersUI struct {
+	fakeUI
+	answers []map[string]any
+}
+
+func (a *answersUI) PromptInputs(inputs []core.InputSpec, defaults map[string]any, ctx PromptContext) (map[string]any, error) {
+	if len(a.answers) == 0 {
+		return nil, ErrUserCanceled
+	}
+	values := a.answers[0]
+	a.answers = a.answers[1:]
+	return values, nil
+}
+
+func TestNewPluginWritesSpecAndNewTaskAppends(t *testing.T) {
+	base := t.TempDir()
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	repo, specDir := createRepoWithLocalSpecsDir(t, base)
+	chdirTo(t, repo)
+
+	ui := &answersUI{answers: []map[string]any{
+		{"id": "tools", "title": "Tools", "kind": kindDirect, "exec": ""},
+		{"name": "greet", "title": "", "group": "Misc", "description": "", "command": "echo {{.args.who}}", "inputs": true},
+		{"name": "who", "type": "enum", "choices": []string{"me", "you"}, "prompt": "Who", "required": true, "default": "me"},
+		{"confirm": false},
+		{"confirm": false},
+	}}
+	var out bytes.Buffer
+	if err := New(ui, []string{"plugin"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	spec, err := core.FindStoredSpec(repo, "tools", core.ScopeLocal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.Path != filepath.Join(specDir, "tools.json") || len(spec.Manifest.Tasks) != 1 {
+		t.Fatalf("unexpected spec %+v", spec)
+	}
+	greet := spec.Manifest.Tasks[0]
+	if strings.Join(greet.Command, " ") != "echo {{.args.who}}" || greet.Title != "greet" || len(greet.Inputs) != 1 || greet.Inputs[0].Choices[1] != "you" {
+		t.Fatalf("unexpected task %+v", greet)
+	}
+
+	ui.answers = []map[string]any{
+		{"spec": "tools"},
+		{"name": "date", "command": "date", "inputs": false},
+	}
+	out.Reset()
+	if err := New(ui, []string{"task"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	spec, err = core.FindStoredSpec(repo, "tools", core.ScopeLocal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spec.Manifest.Tasks) != 2 || spec.Manifest.Tasks[1].Name != "date" {
+		t.Fatalf("expected appended task, got %+v", spec.Manifest.Tasks)
+	}
+}
+
+func TestNewPluginScaffoldsShellPlugin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping shell plugin test on windows")
+	}
+	base := t.TempDir()
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	repo := createRepoWithLocalConfig(t, base)
+	chdirTo(t, repo)
+
+	ui := &answersUI{answers: []map[string]any{
+		{"id": "deploy", "kind": kindPlugin, "language": "sh"},
+		{"name": "staging", "inputs": false},
+		{"confirm": false},
+	}}
+	var out bytes.Buffer
+	if err := New(ui, []string{"plugin"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	plugin := filepath.Join(repo, testLocalConfigDirName, "bin", "deploy")
+	described, err := exec.Command(plugin, "describe").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := core.ParseManifest(described)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Plugin.ID != "deploy" || len(manifest.Tasks) != 1 || manifest.Tasks[0].Name != "staging" {
+		t.Fatalf("unexpected manifest %+v", manifest)
+	}
+	cmd := exec.Command(plugin, "run", "staging")
+	cmd.Stdin = strings.NewReader(`{"args": {}}`)
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(output), "TODO: implement staging") {
+		t.Fatalf("unexpected run output: %s", output)
+	}
+}
@@ -0,0 +1,68 @@
+package app
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+type runOptions struct {
+	args     map[string]string
+	env      map[string]string
+	noInput  bool
+	printEnv bool
+}
+
+// argFlags collects repeated --arg name=value flags.
+type argFlags map[string]string
+
+func (a argFlags) String() string {
+	var parts []string
+	for name, value := range a {
+		parts = append(parts, name+"="+value)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (a argFlags) Set(value string) error {
+	name, raw, ok := strings.Cut(value, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("invalid --arg %q, expected name=value", value)
+	}
+	a[name] = raw
+	return nil
+}
+
+// envFlags collects repeated --env KEY=VAL flags.
+type envFlags map[string]string
+
+func (e envFlags) String() string {
+	return argFlags(e).String()
+}
+
+func (e envFlags) Set(value string) error {
+	name, raw, ok := strings.Cut(value, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("invalid --env %q, expected KEY=VAL", value)
+	}
+	e[name] = raw
+	return nil
+}
+
+func RunTask(uiDriver UI, args []string) error {
+	if len(args) < 1 || strings.HasPrefix(args[0], "-") {
+		return errors.New("usage: automate-me run <taskId> [--arg name=value]... [--env KEY=VAL]... [--no-input] [--print-env]")
+	}
+	id := args[0]
+	opts := runOptions{args: make(argFlags), env: make(envFlags)}
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	fs.Var(argFlags(opts.args), "arg", "input value as name=value (repeatable)")
+	fs.Var(envFlags(opts.env), "env", "environment override as KEY=VAL (repeatable)")
+	fs.BoolVar(&opts.noInput, "no-input", false, "do not prompt; use --arg values and defaults")
+	fs.BoolVar(&opts.printEnv, "print-env", false, "print the environment the task would receive and exit")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	return runTaskByID(uiDriver, id, opts)
+}
@@ -0,0 +1,383 @@
+package app
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ea2809/automate-me/internal/core"
+)
+
+const specsUsage = `usage: automate-me specs <command>
+  list                                  list stored specs with scope and source
+  show <id> [--local|--global]          show a spec's plugin and tasks
+  edit <id> [--local|--global]          edit a spec in $EDITOR
+  rm <id> [--local|--global]            remove a spec
+  mv <id> --to local|global             move a spec to the other spec dir
+  export <id> [--format json|yaml|toml] print a normalized spec
+  update [--local|--global] [--yes]     re-import specs whose source changed
+  migrate [--local|--global]            rewrite specs in the newest schemaVersion`
+
+// Specs dispatches the spec management subcommands.
+func Specs(args []string, in io.Reader, writer io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(specsUsage)
+	}
+	switch args[0] {
+	case "list", "ls":
+		return listSpecs(writer)
+	case "show":
+		return showSpec(args[1:], writer)
+	case "edit":
+		return editSpec(args[1:], in, writer)
+	case "rm", "remove":
+		return removeSpec(args[1:], writer)
+	case "mv", "move":
+		return moveSpec(args[1:], writer)
+	case "export":
+		return exportSpec(args[1:], writer)
+	case "update":
+		return UpdateSpecs(args[1:], in, writer)
+	case "migrate":
+		return migrateSpecs(args[1:], writer)
+	default:
+		return fmt.Errorf("unknown specs command: %s\n%s", args[0], specsUsage)
+	}
+}
+
+// UpdateSpecs re-imports specs whose recorded source changed, showing a
+// diff and asking before each overwrite.
+func UpdateSpecs(args []string, in io.Reader, writer io.Writer) error {
+	fs := flag.NewFlagSet("specs update", flag.ContinueOnError)
+	var useGlobal bool
+	var useLocal bool
+	var yes bool
+	fs.BoolVar(&useGlobal, "global", false, "only update global specs")
+	fs.BoolVar(&useLocal, "local", false, "only update repo specs")
+	fs.BoolVar(&yes, "yes", false, "apply updates without asking")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if useGlobal && useLocal {
+		return errors.New("use only one of --global or --local")
+	}
+	repoRoot, err := currentRepoRoot()
+	if err != nil {
+		return err
+	}
+	var scopes []core.PluginScope
+	if !useGlobal && repoRoot != "" {
+		scopes = append(scopes, core.ScopeLocal)
+	}
+	if !useLocal {
+		scopes = append(scopes, core.ScopeGlobal)
+	}
+	if len(scopes) == 0 {
+		return errors.New("not in a repo")
+	}
+	reader := bufio.NewReader(in)
+	updated := 0
+	for _, scope := range scopes {
+		updates, failed, err := core.SpecUpdates(repoRoot, scope)
+		if err != nil {
+			return err
+		}
+		failedSources := make([]string, 0, len(failed))
+		for source := range failed {
+			failedSources = append(failedSources, source)
+		}
+		sort.Strings(failedSources)
+		var diagnostics core.Diagnostics
+		for _, source := range failedSources {
+			diagnostics.Warn(source, "cannot check spec source "+source, failed[source])
+		}
+		diagnostics.Print(os.Stderr)
+		for _, update := range updates {
+			if !update.Changed() {
+				if err := update.Write(); err != nil {
+					return err
+				}
+				continue
+			}
+			fmt.Fprintf(writer, "--- %s\n+++ %s\n", update.DestPath, update.Source)
+			fmt.Fprint(writer, core.DiffLines(update.Existing, update.Data))
+			if !yes {
+				ok, err := confirm(reader, writer, "Apply this update? [y/N] ")
+				if err != nil {
+					return err
+				}
+				if !ok {
+					fmt.Fprintf(writer, "skipped %s\n", update.DestPath)
+					continue
+				}
+			}
+			if err := update.Write(); err != nil {
+				return err
+			}
+			fmt.Fprintf(writer, "updated %s\n", update.DestPath)
+			updated++
+		}
+	}
+	if updated == 0 {
+		fmt.Fprintln(writer, "specs are up to date")
+	}
+	return nil
+}
+
+func listSpecs(writer io.Writer) error {
+	repoRoot, err := currentRepoRoot()
+	if err != nil {
+		return err
+	}
+	specs, diagnostics, err := core.ListStoredSpecs(repoRoot)
+	if err != nil {
+		return err
+	}
+	diagnostics.Print(os.Stderr)
+	if len(specs) == 0 {
+		fmt.Fprintln(writer, "no specs found")
+		return nil
+	}
+	for _, spec := range specs {
+		source := spec.Source
+		if source == "" {
+			source = "-"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", spec.ID, spec.Scope, spec.Path, source)
+	}
+	return nil
+}
+
+func showSpec(args []string, writer io.Writer) error {
+	spec, err := findSpecArg(flag.NewFlagSet("specs show", flag.ContinueOnError), args)
+	if err != nil {
+		return err
+	}
+	plugin := spec.Manifest.Plugin
+	fmt.Fprintf(writer, "id:\t%s\ntitle:\t%s\nscope:\t%s\npath:\t%s\n", plugin.ID, plugin.Title, spec.Scope, spec.Path)
+	if spec.Source != "" {
+		fmt.Fprintf(writer, "source:\t%s\n", spec.Source)
+	}
+	if plugin.Exec != "" {
+		fmt.Fprintf(writer, "exec:\t%s\n", plugin.Exec)
+	}
+	fmt.Fprintln(writer, "tasks:")
+	for _, task := range spec.Manifest.Tasks {
+		fmt.Fprintf(writer, "  %s\t%s\t%s\n", core.TaskID(plugin.ID, task.Name), task.Title, task.Description)
+	}
+	return nil
+}
+
+// editSpec opens a copy of the spec in $EDITOR and saves it once it passes
+// validation, offering to edit again when it does not.
+func editSpec(args []string, in io.Reader, writer io.Writer) error {
+	spec, err := findSpecArg(flag.NewFlagSet("specs edit", flag.ContinueOnError), args)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(spec.Path)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp("", "automate-me-spec-*"+filepath.Ext(spec.Path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	reader := bufio.NewReader(in)
+	for {
+		if err := runEditor(tmp.Name()); err != nil {
+			return err
+		}
+		edited, err := os.ReadFile(tmp.Name())
+		if err != nil {
+			return err
+		}
+		if string(edited) == string(data) {
+			fmt.Fprintln(writer, "no changes")
+			return nil
+		}
+		err = core.SaveStoredSpec(spec, edited)
+		if err == nil {
+			fmt.Fprintf(writer, "saved %s\n", spec.Path)
+			return nil
+		}
+		fmt.Fprintf(writer, "invalid spec: %v\n", err)
+		ok, err := confirm(reader, writer, "Edit again? [y/N] ")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrUserCanceled
+		}
+	}
+}
+
+func runEditor(path string) error {
+	editor := strings.Fields(os.Getenv("VISUAL"))
+	if len(editor) == 0 {
+		editor = strings.Fields(os.Getenv("EDITOR"))
+	}
+	if len(editor) == 0 {
+		editor = []string{"vi"}
+	}
+	cmd := exec.Command(editor[0], append(editor[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("run editor: %w", err)
+	}
+	return nil
+}
+
+func removeSpec(args []string, writer io.Writer) error {
+	spec, err := findSpecArg(flag.NewFlagSet("specs rm", flag.ContinueOnError), args)
+	if err != nil {
+		return err
+	}
+	if err := core.RemoveStoredSpec(spec); err != nil {
+		return err
+	}
+	fmt.Fprintf(writer, "removed %s\n", spec.Path)
+	return nil
+}
+
+func moveSpec(args []string, writer io.Writer) error {
+	fs := flag.NewFlagSet("specs mv", flag.ContinueOnError)
+	var to string
+	fs.StringVar(&to, "to", "", "destination scope: local or global")
+	ids, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+	scope := core.PluginScope(to)
+	if len(ids) != 1 || (scope != core.ScopeLocal && scope != core.ScopeGlobal) {
+		return errors.New("usage: automate-me specs mv <id> --to local|global")
+	}
+	repoRoot, err := currentRepoRoot()
+	if err != nil {
+		return err
+	}
+	from := core.ScopeLocal
+	if scope == core.ScopeLocal {
+		from = core.ScopeGlobal
+	}
+	spec, err := core.FindStoredSpec(repoRoot, ids[0], from)
+	if err != nil {
+		return err
+	}
+	destPath, err := core.MoveStoredSpec(spec, repoRoot, scope)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(writer, "moved %s -> %s\n", spec.Path, destPath)
+	return nil
+}
+
+func exportSpec(args []string, writer io.Writer) error {
+	fs := flag.NewFlagSet("specs export", flag.ContinueOnError)
+	var format string
+	fs.StringVar(&format, "format", core.FormatJSON, "output format: json, yaml or toml")
+	spec, err := findSpecArg(fs, args)
+	if err != nil {
+		return err
+	}
+	data, err := core.ExportSpecFormat(spec.Manifest, format)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(data)
+	return err
+}
+
+// findSpecArg parses "<id> [--local|--global]", plus the flags already
+// defined on fs, and looks the spec up.
+func findSpecArg(fs *flag.FlagSet, args []string) (core.StoredSpec, error) {
+	var useGlobal bool
+	var useLocal bool
+	fs.BoolVar(&useGlobal, "global", false, "use the global spec")
+	fs.BoolVar(&useLocal, "local", false, "use the repo spec")
+	ids, err := parseInterleaved(fs, args)
+	if err != nil {
+		return core.StoredSpec{}, err
+	}
+	if len(ids) != 1 {
+		return core.StoredSpec{}, fmt.Errorf("usage: automate-me %s <id> [--local|--global]", fs.Name())
+	}
+	if useGlobal && useLocal {
+		return core.StoredSpec{}, errors.New("use only one of --global or --local")
+	}
+	var scope core.PluginScope
+	switch {
+	case useGlobal:
+		scope = core.ScopeGlobal
+	case useLocal:
+		scope = core.ScopeLocal
+	}
+	repoRoot, err := currentRepoRoot()
+	if err != nil {
+		return core.StoredSpec{}, err
+	}
+	return core.FindStoredSpec(repoRoot, ids[0], scope)
+}
+
+func migrateSpecs(args []string, writer io.Writer) error {
+	fs := flag.NewFlagSet("specs migrate", flag.ContinueOnError)
+	var useGlobal bool
+	var useLocal bool
+	fs.BoolVar(&useGlobal, "global", false, "only migrate global specs")
+	fs.BoolVar(&useLocal, "local", false, "only migrate repo specs")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if useGlobal && useLocal {
+		return errors.New("use only one of --global or --local")
+	}
+	repoRoot, err := currentRepoRoot()
+	if err != nil {
+		return err
+	}
+	specs, diagnostics, err := core.ListStoredSpecs(repoRoot)
+	if err != nil {
+		return err
+	}
+	diagnostics.Print(os.Stderr)
+	migrated := 0
+	for _, spec := range specs {
+		if (useGlobal && spec.Scope != core.ScopeGlobal) || (useLocal && spec.Scope != core.ScopeLocal) {
+			continue
+		}
+		from, err := core.MigrateStoredSpec(spec)
+		if errors.Is(err, core.ErrManualMigration) {
+			fmt.Fprintf(writer, "skipped %s: %v\n", spec.Path, err)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", spec.Path, err)
+		}
+		if from == core.CurrentSchemaVersion {
+			continue
+		}
+		fmt.Fprintf(writer, "migrated %s (schemaVersion %d -> %d)\n", spec.Path, from, core.CurrentSchemaVersion)
+		migrated++
+	}
+	if migrated == 0 {
+		fmt.Fprintf(writer, "all specs use schemaVersion %d\n", core.CurrentSchemaVersion)
+	}
+	return nil
+}
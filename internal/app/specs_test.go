@@ -0,0 +1,202 @@
+package app
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestImportSpecPromptsOnCollision(t *testing.T) {
+	base := t.TempDir()
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	repo, specDir := createRepoWithLocalSpecsDir(t, base)
+	srcDir := filepath.Join(base, "src")
+	if err := os.MkdirAll(srcDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeSpecFile(t, srcDir, "a.json", `{"schemaVersion":1,"plugin":{"id":"dup","title":"A","exec":"/bin/echo"},"tasks":[{"name":"t"}]}`)
+	writeSpecFile(t, srcDir, "b.json", `{"schemaVersion":1,"plugin":{"id":"dup","title":"B","exec":"/bin/echo"},"tasks":[{"name":"t"}]}`)
+	chdirTo(t, repo)
+
+	var out bytes.Buffer
+	if err := ImportSpec([]string{srcDir}, strings.NewReader("n\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "spec id dup already imported from "+filepath.Join(srcDir, "a.json")) {
+		t.Fatalf("expected collision prompt, got: %s", out.String())
+	}
+	data, err := os.ReadFile(filepath.Join(specDir, "dup.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"title":"A"`) {
+		t.Fatalf("expected declined import to keep first spec, got %s", data)
+	}
+}
+
+func TestImportSpecAnswersSeveralPrompts(t *testing.T) {
+	base := t.TempDir()
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	repo, specDir := createRepoWithLocalSpecsDir(t, base)
+	for _, src := range []string{"old", "new"} {
+		dir := filepath.Join(base, src)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		for _, id := range []string{"a", "b"} {
+			writeSpecFile(t, dir, id+".json", `{"schemaVersion":2,"plugin":{"id":"`+id+`","title":"`+src+`","exec":"/bin/echo"},"tasks":[{"name":"t"}]}`)
+		}
+	}
+	chdirTo(t, repo)
+
+	var out bytes.Buffer
+	if err := ImportSpec([]string{filepath.Join(base, "old")}, strings.NewReader(""), &out); err != nil {
+		t.Fatal(err)
+	}
+	if err := ImportSpec([]string{filepath.Join(base, "new")}, strings.NewReader("y\ny\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"a", "b"} {
+		data, err := os.ReadFile(filepath.Join(specDir, id+".json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), `"title":"new"`) {
+			t.Fatalf("expected %s to be replaced after answering yes, got %s\n%s", id, data, out.String())
+		}
+	}
+}
+
+func TestUpdateSpecsReportsFailedSourcesOnStderr(t *testing.T) {
+	base := t.TempDir()
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	repo, _ := createRepoWithLocalSpecsDir(t, base)
+	srcDir := filepath.Join(base, "src")
+	if err := os.MkdirAll(srcDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeSpecFile(t, srcDir, "a.json", `{"schemaVersion":2,"plugin":{"id":"a","title":"A","exec":"/bin/echo"},"tasks":[{"name":"t"}]}`)
+	chdirTo(t, repo)
+	var out bytes.Buffer
+	if err := ImportSpec([]string{srcDir}, strings.NewReader(""), &out); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(srcDir); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if err := UpdateSpecs([]string{"--local"}, strings.NewReader(""), &out); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "warning") {
+		t.Fatalf("expected source failures on stderr, not in the output: %s", out.String())
+	}
+}
+
+func TestUpdateSpecsShowsDiff(t *testing.T) {
+	base := t.TempDir()
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	repo, specDir := createRepoWithLocalSpecsDir(t, base)
+	src := filepath.Join(base, "spec.json")
+	if err := os.WriteFile(src, []byte("{\"schemaVersion\":1,\n\"plugin\":{\"id\":\"up\",\"title\":\"Old\",\"exec\":\"/bin/echo\"},\n\"tasks\":[{\"name\":\"t\"}]}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	chdirTo(t, repo)
+
+	var out bytes.Buffer
+	if err := ImportSpec([]string{src, "--local"}, strings.NewReader(""), &out); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(src, []byte("{\"schemaVersion\":1,\n\"plugin\":{\"id\":\"up\",\"title\":\"New\",\"exec\":\"/bin/echo\"},\n\"tasks\":[{\"name\":\"t\"}]}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	if err := Specs([]string{"update", "--local"}, strings.NewReader("y\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `-"plugin":{"id":"up","title":"Old"`) || !strings.Contains(out.String(), `+"plugin":{"id":"up","title":"New"`) {
+		t.Fatalf("expected diff in output, got: %s", out.String())
+	}
+	data, err := os.ReadFile(filepath.Join(specDir, "up.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"title":"New"`) {
+		t.Fatalf("expected updated spec, got %s", data)
+	}
+}
+
+func TestSpecsListShowExport(t *testing.T) {
+	base := t.TempDir()
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	repo, specDir := createRepoWithLocalSpecsDir(t, base)
+	writeSpecFile(t, specDir, "repo.json", `{"schemaVersion":1,"plugin":{"id":"repo","title":"Repo","exec":"/bin/echo","extra":true},"tasks":[{"name":"test"}]}`)
+	chdirTo(t, repo)
+
+	var out bytes.Buffer
+	if err := Specs([]string{"list"}, strings.NewReader(""), &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out.String(), "repo\tlocal\t"+filepath.Join(specDir, "repo.json")+"\t-") {
+		t.Fatalf("unexpected list output: %s", out.String())
+	}
+
+	out.Reset()
+	if err := Specs([]string{"show", "repo"}, strings.NewReader(""), &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "repo:test\ttest") {
+		t.Fatalf("unexpected show output: %s", out.String())
+	}
+
+	out.Reset()
+	if err := Specs([]string{"export", "repo", "--local"}, strings.NewReader(""), &out); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "extra") || !strings.Contains(out.String(), `"title": "test"`) {
+		t.Fatalf("expected normalized export, got: %s", out.String())
+	}
+}
+
+func TestSpecsEditRejectsInvalid(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping shell editor test on windows")
+	}
+	base := t.TempDir()
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	repo, specDir := createRepoWithLocalSpecsDir(t, base)
+	original := `{"schemaVersion":1,"plugin":{"id":"repo","title":"Repo","exec":"/bin/echo"},"tasks":[{"name":"test"}]}`
+	writeSpecFile(t, specDir, "repo.json", original)
+	editor := filepath.Join(base, "editor.sh")
+	if err := os.WriteFile(editor, []byte("#!/bin/sh\necho '{\"schemaVersion\": 3}' > \"$1\"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", editor)
+	chdirTo(t, repo)
+
+	var out bytes.Buffer
+	if err := Specs([]string{"edit", "repo"}, strings.NewReader("n\n"), &out); err != ErrUserCanceled {
+		t.Fatalf("expected canceled edit, got %v (%s)", err, out.String())
+	}
+	if !strings.Contains(out.String(), "invalid spec: unsupported schemaVersion: 3") {
+		t.Fatalf("unexpected edit output: %s", out.String())
+	}
+	data, err := os.ReadFile(filepath.Join(specDir, "repo.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != original {
+		t.Fatalf("expected spec untouched, got %s", data)
+	}
+}
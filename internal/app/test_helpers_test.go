@@ -4,6 +4,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/ea2809/automate-me/internal/core"
 )
 
 const (
@@ -52,3 +54,11 @@ func chdirTo(t *testing.T, dir string) {
 		t.Fatal(err)
 	}
 }
+
+// trustRepo trusts the local plugins and specs written to repo so far.
+func trustRepo(t *testing.T, repo string) {
+	t.Helper()
+	if _, err := core.TrustRepo(repo); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,110 @@
+package app
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ea2809/automate-me/internal/core"
+)
+
+func Trust(args []string, in io.Reader, writer io.Writer) error {
+	fs := flag.NewFlagSet("trust", flag.ContinueOnError)
+	var revoke bool
+	var yes bool
+	fs.BoolVar(&revoke, "revoke", false, "stop trusting the current repo")
+	fs.BoolVar(&yes, "yes", false, "trust changed plugins without asking")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	repoRoot, err := currentRepoRoot()
+	if err != nil {
+		return err
+	}
+	if repoRoot == "" {
+		return errors.New("not in a repo")
+	}
+	if revoke {
+		if err := core.RevokeTrust(repoRoot); err != nil {
+			return err
+		}
+		fmt.Fprintf(writer, "revoked trust for %s\n", repoRoot)
+		return nil
+	}
+	report, err := core.VerifyTrust(repoRoot)
+	if err != nil {
+		return err
+	}
+	if report.Trusted && !report.Clean() && !yes {
+		writeTrustReport(writer, report)
+		ok, err := confirm(bufio.NewReader(in), writer, "Trust these changes? [y/N] ")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrUserCanceled
+		}
+	}
+	paths, err := core.TrustRepo(repoRoot)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(writer, "trusted %s\n", repoRoot)
+	for _, path := range paths {
+		fmt.Fprintf(writer, "  %s\n", path)
+	}
+	return nil
+}
+
+func VerifyPluginsWithWriter(writer io.Writer) error {
+	repoRoot, err := currentRepoRoot()
+	if err != nil {
+		return err
+	}
+	report, err := core.VerifyTrust(repoRoot)
+	if err != nil {
+		return err
+	}
+	if !report.Trusted {
+		fmt.Fprintf(writer, "%s is not trusted; run `%s trust`\n", repoRoot, AppName)
+		return nil
+	}
+	if report.Clean() {
+		fmt.Fprintf(writer, "no changes since trusted at %s\n", report.TrustedAt.Format("2006-01-02 15:04:05"))
+		return nil
+	}
+	writeTrustReport(writer, report)
+	return fmt.Errorf("local plugins changed since trusted; run `%s trust` to accept", AppName)
+}
+
+func writeTrustReport(writer io.Writer, report core.TrustReport) {
+	for _, path := range report.Changed {
+		fmt.Fprintf(writer, "changed\t%s\n", path)
+	}
+	for _, path := range report.New {
+		fmt.Fprintf(writer, "new\t%s\n", path)
+	}
+	for _, path := range report.Removed {
+		fmt.Fprintf(writer, "removed\t%s\n", path)
+	}
+}
+
+// confirm asks a yes/no question and reads the answer from reader. Commands
+// that ask several questions share one reader, so piped answers buffered
+// for a later question are not lost.
+func confirm(reader *bufio.Reader, writer io.Writer, prompt string) (bool, error) {
+	fmt.Fprint(writer, prompt)
+	line, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
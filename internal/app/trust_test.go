@@ -0,0 +1,57 @@
+package app
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestTrustAsksBeforeAcceptingChanges(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping exec bit test on windows")
+	}
+	base := t.TempDir()
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	repo := createRepoWithLocalConfig(t, base)
+	binDir := filepath.Join(repo, testLocalConfigDirName, "bin")
+	if err := os.MkdirAll(binDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	plugin := filepath.Join(binDir, "plugin")
+	if err := os.WriteFile(plugin, []byte("#!/bin/sh\nexit 0\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	chdirTo(t, repo)
+
+	var out bytes.Buffer
+	if err := Trust(nil, strings.NewReader(""), &out); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(plugin, []byte("#!/bin/sh\nexit 1\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	if err := VerifyPluginsWithWriter(&out); err == nil {
+		t.Fatal("expected verify to fail on changed plugin")
+	}
+	if !strings.Contains(out.String(), "changed\t"+plugin) {
+		t.Fatalf("unexpected verify output: %s", out.String())
+	}
+
+	out.Reset()
+	if err := Trust(nil, strings.NewReader("n\n"), &out); err != ErrUserCanceled {
+		t.Fatalf("expected declined trust to cancel, got %v", err)
+	}
+	if err := Trust(nil, strings.NewReader("y\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if err := VerifyPluginsWithWriter(&out); err != nil {
+		t.Fatalf("expected clean verify after trust: %v (%s)", err, out.String())
+	}
+}
@@ -4,16 +4,33 @@ import "github.com/ea2809/automate-me/internal/core"
 
 type UI interface {
 	ClearScreen()
-	SelectTask(tasks []core.TaskRecord, state SelectionState) (core.TaskRecord, SelectionState, error)
-	PromptInputs(inputs []core.InputSpec, defaults map[string]any) (map[string]any, error)
+	SelectTask(tasks []core.TaskRecord, state SelectionState, diagnostics core.Diagnostics) (core.TaskRecord, SelectionState, error)
+	PromptInputs(inputs []core.InputSpec, defaults map[string]any, ctx PromptContext) (map[string]any, error)
 	RenderRunning(taskID, pluginTitle string)
 	RenderLoading(message string)
 	WaitForEnter() error
 }
 
+// ChoicesFunc resolves the choices of an input declaring choicesFrom, given
+// the values collected so far. UIs call it right before prompting.
+type ChoicesFunc func(input core.InputSpec, values map[string]any) ([]string, error)
+
+// PromptContext carries what UIs need to resolve inputs while prompting:
+// the roots for path inputs, the resolver for choicesFrom inputs and the
+// preset values, whose inputs are not asked but count for when conditions.
+type PromptContext struct {
+	RepoRoot string
+	Cwd      string
+	Choices  ChoicesFunc
+	Preset   map[string]any
+}
+
 // SelectionState keeps the UI cursor and filter between runs.
 // This is owned by app to keep UIs decoupled.
 type SelectionState struct {
 	Filter string
 	Cursor int
+	// DiagnosticsDismissed hides the diagnostics banner until the next
+	// refresh.
+	DiagnosticsDismissed bool
 }
@@ -19,7 +19,8 @@ func resolveRepoAndTasks(cwd string) (string, []core.TaskRecord, error) {
 	if err != nil {
 		return "", nil, err
 	}
-	tasks, err := loadTasks(repoRoot)
+	tasks, diagnostics, err := loadTasks(repoRoot)
+	diagnostics.Print(os.Stderr)
 	if err != nil {
 		return "", nil, err
 	}
@@ -0,0 +1,74 @@
+package app
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/ea2809/automate-me/internal/core"
+)
+
+// loadWorkspaceTasks aggregates the tasks of every workspace repo. Tasks of
+// a repo's own plugins are prefixed with the repo name; global plugins are
+// loaded once, with the current repo, and their tasks listed unprefixed. A
+// repo that fails to load is reported as a diagnostic instead of hiding the
+// others.
+func loadWorkspaceTasks(repoRoot string, members []core.WorkspaceRepo) ([]core.TaskRecord, core.Diagnostics, int, error) {
+	var tasks []core.TaskRecord
+	var diagnostics core.Diagnostics
+	untrusted := 0
+	if repoRoot == "" {
+		globals, globalDiagnostics, _, err := loadRepoTasks("", true)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		tasks = append(tasks, globals...)
+		diagnostics = append(diagnostics, globalDiagnostics...)
+	}
+	for _, member := range members {
+		current := member.Root == repoRoot
+		memberTasks, memberDiagnostics, memberUntrusted, err := loadRepoTasks(member.Root, current)
+		if err != nil {
+			diagnostics.Error(member.Root, "workspace repo "+member.Name, err)
+			continue
+		}
+		untrusted += memberUntrusted
+		for _, diagnostic := range memberDiagnostics {
+			// Problems with global plugins are reported once.
+			if current || withinRoot(diagnostic.Source, member.Root) {
+				diagnostics = append(diagnostics, diagnostic)
+			}
+		}
+		for _, task := range memberTasks {
+			if task.Scope == core.ScopeGlobal {
+				if current {
+					tasks = append(tasks, task)
+				}
+				continue
+			}
+			task.Repo = member.Name
+			task.RepoRoot = member.Root
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks, diagnostics, untrusted, nil
+}
+
+// taskRoots returns the repo root and cwd a task runs with. Workspace tasks
+// run in their own repo, from cwd only when it is inside that repo.
+func taskRoots(task core.TaskRecord, repoRoot, cwd string) (string, string) {
+	if task.RepoRoot == "" || task.RepoRoot == repoRoot {
+		return repoRoot, cwd
+	}
+	if withinRoot(cwd, task.RepoRoot) {
+		return task.RepoRoot, cwd
+	}
+	return task.RepoRoot, task.RepoRoot
+}
+
+func withinRoot(path, root string) bool {
+	if path == "" {
+		return false
+	}
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestWorkspaceTasks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping shell script test on windows")
+	}
+	base := t.TempDir()
+	repo, specDir := createRepoWithLocalSpecsDir(t, base)
+	member := filepath.Join(base, "projects", "api")
+	memberSpecDir := filepath.Join(member, testLocalConfigDirName, testSpecsDirName)
+	if err := os.MkdirAll(memberSpecDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	outputFile := filepath.Join(base, "out.txt")
+	script := filepath.Join(base, "run.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\ncat > \"$OUTPUT_FILE\"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("OUTPUT_FILE", outputFile)
+	defer os.Unsetenv("OUTPUT_FILE")
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+
+	globalBin := filepath.Join(base, "config", "automate-me", "bin")
+	if err := os.MkdirAll(globalBin, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	describes := filepath.Join(base, "describes")
+	global := "#!/bin/sh\necho x >> \"" + describes + "\"\necho '{\"schemaVersion\":2,\"plugin\":{\"id\":\"g\"},\"tasks\":[{\"name\":\"t\"}]}'\n"
+	if err := os.WriteFile(filepath.Join(globalBin, "g"), []byte(global), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := `{"schemaVersion": 2, "plugin": {"id": "p", "title": "P", "exec": "` + script + `"}, "tasks": [{"name": "t", "title": "t"}]}`
+	writeSpecFile(t, specDir, "p.json", spec)
+	writeSpecFile(t, memberSpecDir, "p.json", spec)
+	config := `{"workspace": ["../projects/*"]}`
+	if err := os.WriteFile(filepath.Join(repo, testLocalConfigDirName, "config.json"), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	trustRepo(t, repo)
+	trustRepo(t, member)
+	chdirTo(t, repo)
+
+	var out bytes.Buffer
+	if err := ListTasksWithWriter(&out); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "api/p:t\t") || !strings.HasPrefix(lines[1], "g:t\t") || !strings.HasPrefix(lines[2], "repo/p:t\t") {
+		t.Fatalf("expected tasks prefixed with repo names, got %q", out.String())
+	}
+	data, err := os.ReadFile(describes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(data), "x") != 1 {
+		t.Fatalf("expected the global plugin to be described once, got %d", strings.Count(string(data), "x"))
+	}
+
+	if err := RunTask(fakeUI{}, []string{"api/p:t", "--no-input"}); err != nil {
+		t.Fatal(err)
+	}
+	data, err = os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"repoRoot":"`+member+`"`) || !strings.Contains(string(data), `"selectedTaskId":"p:t"`) {
+		t.Fatalf("expected task to run in %s, got %s", member, data)
+	}
+}
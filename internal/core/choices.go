@@ -0,0 +1,56 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+)
+
+// ResolveChoices asks a protocol plugin for the choices of an input that
+// declares choicesFrom. The args collected so far are sent on stdin so the
+// plugin can compute dependent choices (e.g. branches of a selected project).
+// The plugin gets the environment the task would, with the same overrides,
+// and its stderr is redacted like task output.
+func ResolveChoices(task TaskRecord, input InputSpec, repoRoot, cwd string, args map[string]any, overrides map[string]string) ([]string, error) {
+	if input.ChoicesFrom == "" {
+		return input.Choices, nil
+	}
+	if task.DirectExec {
+		return nil, fmt.Errorf("input %s: choicesFrom requires a protocol plugin", input.Name)
+	}
+	payload, err := taskPayload(task, repoRoot, cwd, args)
+	if err != nil {
+		return nil, err
+	}
+	env, err := TaskEnv(task, repoRoot, cwd, overrides)
+	if err != nil {
+		return nil, err
+	}
+	workdir, err := TaskWorkdir(task, repoRoot, cwd)
+	if err != nil {
+		return nil, err
+	}
+	redactor, err := TaskRedactor(task, repoRoot, args, env)
+	if err != nil {
+		return nil, err
+	}
+	_, stderr, flush := outputWriters(redactor)
+	cmd := exec.Command(task.PluginPath, "choices", task.Task.Name, input.ChoicesFrom)
+	cmd.Dir = workdir
+	var stdout bytes.Buffer
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = &stdout
+	cmd.Stderr = stderr
+	cmd.Env = env
+	err = cmd.Run()
+	flush()
+	if err != nil {
+		return nil, fmt.Errorf("resolve choices for %s: %w", input.Name, err)
+	}
+	var choices []string
+	if err := json.Unmarshal(stdout.Bytes(), &choices); err != nil {
+		return nil, fmt.Errorf("invalid choices JSON for %s: %w", input.Name, err)
+	}
+	return choices, nil
+}
@@ -0,0 +1,98 @@
+package core
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestResolveChoicesFromPlugin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping shell script test on windows")
+	}
+	base := t.TempDir()
+	outputFile := filepath.Join(base, "stdin.json")
+	script := filepath.Join(base, "plugin.sh")
+	content := "#!/bin/sh\n" +
+		"if [ \"$1\" != \"choices\" ] || [ \"$2\" != \"checkout\" ] || [ \"$3\" != \"branch\" ]; then exit 2; fi\n" +
+		"cat > \"$OUTPUT_FILE\"\n" +
+		"echo '[\"main\",\"dev\"]'\n"
+	if err := os.WriteFile(script, []byte(content), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("OUTPUT_FILE", outputFile)
+	defer os.Unsetenv("OUTPUT_FILE")
+
+	task := TaskRecord{
+		PluginID:   "git",
+		Task:       TaskSpec{Name: "checkout"},
+		PluginPath: script,
+		Scope:      ScopeLocal,
+	}
+	input := InputSpec{Name: "branch", Type: "enum", ChoicesFrom: "branch"}
+	choices, err := ResolveChoices(task, input, base, base, map[string]any{"project": "api"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(choices) != 2 || choices[0] != "main" || choices[1] != "dev" {
+		t.Fatalf("unexpected choices: %v", choices)
+	}
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) == 0 {
+		t.Fatal("expected collected args on stdin")
+	}
+}
+
+func TestResolveChoicesDirectExec(t *testing.T) {
+	task := TaskRecord{PluginID: "p", Task: TaskSpec{Name: "t"}, PluginPath: "/bin/echo", DirectExec: true}
+	input := InputSpec{Name: "x", Type: "enum", ChoicesFrom: "x"}
+	if _, err := ResolveChoices(task, input, "", "", nil, nil); err == nil {
+		t.Fatal("expected error for direct exec plugin")
+	}
+}
+
+func TestResolveChoicesRedactsStderr(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping shell script test on windows")
+	}
+	base := t.TempDir()
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	script := filepath.Join(base, "plugin.sh")
+	content := "#!/bin/sh\ncat >&2\necho >&2\necho '[\"a\"]'\n"
+	if err := os.WriteFile(script, []byte(content), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	task := TaskRecord{
+		PluginID:   "p",
+		Task:       TaskSpec{Name: "t", Inputs: []InputSpec{{Name: "token", Type: "string", Secret: true}}},
+		PluginPath: script,
+		Scope:      ScopeGlobal,
+	}
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = writer
+	input := InputSpec{Name: "x", Type: "enum", ChoicesFrom: "x"}
+	_, err = ResolveChoices(task, input, base, base, map[string]any{"token": "s3cret-value"}, nil)
+	os.Stderr = stderr
+	writer.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(out), "s3cret-value") || !strings.Contains(string(out), "selectedTaskId") {
+		t.Fatalf("expected secret redacted from choices stderr, got %q", out)
+	}
+}
@@ -0,0 +1,214 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// wholeArgTemplate matches an element that is exactly one {{.args.name}}
+// action, so list values can be spread over several arguments.
+var wholeArgTemplate = regexp.MustCompile(`^\{\{\s*\.args\.([A-Za-z0-9_]+)\s*\}\}$`)
+
+var commandFuncs = template.FuncMap{"join": strings.Join}
+
+// commandAction and commandRef find the .args and .ctx fields a command
+// element refers to, so typos are rejected when the manifest is parsed.
+var (
+	commandAction = regexp.MustCompile(`\{\{.*?\}\}`)
+	commandRef    = regexp.MustCompile(`\.(args|ctx)\.([A-Za-z0-9_]+)`)
+)
+
+// commandContextKeys are the fields of .ctx in command templates.
+var commandContextKeys = []string{"repoRoot", "outerRepoRoot", "cwd", "workdir", "taskId"}
+
+// directCommand builds the argv of a direct exec task: the task's command
+// (or plugin.exec) followed by its args, each element rendered as a Go
+// template with .args (input values) and .ctx (repoRoot, outerRepoRoot,
+// cwd, workdir, taskId). Templated elements that render empty are dropped.
+// With shell the rendered elements are joined into one script run by the
+// system shell and every input and context value is shell-quoted.
+func directCommand(task TaskRecord, repoRoot, cwd, workdir string, args map[string]any) ([]string, error) {
+	elements := task.Task.Command
+	if len(elements) == 0 {
+		elements = []string{task.PluginPath}
+	}
+	elements = append(append([]string(nil), elements...), task.Task.Args...)
+	shell := task.Task.Shell
+	ctx := map[string]string{
+		"repoRoot":      repoRoot,
+		"outerRepoRoot": OuterRepoRoot(repoRoot),
+		"cwd":           cwd,
+		"workdir":       workdir,
+		"taskId":        TaskID(task.PluginID, task.Task.Name),
+	}
+	if shell {
+		for key, value := range ctx {
+			ctx[key] = shellQuote(value)
+		}
+	}
+	data := map[string]any{
+		"args": commandArgs(task.Task.Inputs, args, shell),
+		"ctx":  ctx,
+	}
+	var argv []string
+	for _, element := range elements {
+		if !strings.Contains(element, "{{") {
+			argv = append(argv, element)
+			continue
+		}
+		if match := wholeArgTemplate.FindStringSubmatch(strings.TrimSpace(element)); match != nil && !shell {
+			if list, ok := data["args"].(map[string]any)[match[1]].([]string); ok {
+				argv = append(argv, list...)
+				continue
+			}
+		}
+		rendered, err := renderCommandElement(element, data)
+		if err != nil {
+			return nil, fmt.Errorf("task %s: %w", TaskID(task.PluginID, task.Task.Name), err)
+		}
+		if rendered != "" {
+			argv = append(argv, rendered)
+		}
+	}
+	if len(argv) == 0 || argv[0] == "" {
+		return nil, fmt.Errorf("task %s: empty command", TaskID(task.PluginID, task.Task.Name))
+	}
+	if shell {
+		script := strings.Join(argv, " ")
+		if runtime.GOOS == "windows" {
+			return []string{"cmd", "/C", script}, nil
+		}
+		return []string{"sh", "-c", script}, nil
+	}
+	execRoot := repoRoot
+	if task.LevelRoot != "" {
+		execRoot = task.LevelRoot
+	}
+	argv[0] = commandPath(argv[0], execRoot)
+	return argv, nil
+}
+
+// commandPath resolves a program given with a relative path against the
+// root of the spec's .automate-me level, matching how spec execs are
+// pinned.
+func commandPath(program, repoRoot string) string {
+	if repoRoot == "" || filepath.IsAbs(program) {
+		return program
+	}
+	if strings.ContainsRune(program, '/') || strings.ContainsRune(program, filepath.Separator) {
+		return filepath.Join(repoRoot, program)
+	}
+	return program
+}
+
+// commandArgs formats every declared input (empty when not collected) as a
+// string, or a []string for lists. In shell mode values are quoted instead.
+func commandArgs(inputs []InputSpec, args map[string]any, shell bool) map[string]any {
+	out := make(map[string]any)
+	for _, input := range inputs {
+		out[input.Name] = ""
+	}
+	for name, value := range args {
+		if i := inputIndex(inputs, name); i >= 0 && inputs[i].Secret {
+			continue
+		}
+		formatted := commandValue(value)
+		if !shell {
+			out[name] = formatted
+			continue
+		}
+		if list, ok := formatted.([]string); ok {
+			quoted := make([]string, len(list))
+			for i, item := range list {
+				quoted[i] = shellQuote(item)
+			}
+			out[name] = strings.Join(quoted, " ")
+			continue
+		}
+		out[name] = shellQuote(formatted.(string))
+	}
+	return out
+}
+
+func commandValue(value any) any {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []string:
+		return append([]string(nil), v...)
+	case []any:
+		out := make([]string, len(v))
+		for i, item := range v {
+			out[i] = fmt.Sprint(item)
+		}
+		return out
+	case map[string]string:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		parts := make([]string, len(keys))
+		for i, key := range keys {
+			parts[i] = key + "=" + v[key]
+		}
+		return strings.Join(parts, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func renderCommandElement(element string, data map[string]any) (string, error) {
+	tmpl, err := parseCommandElement(element)
+	if err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("render %q: %w", element, err)
+	}
+	return out.String(), nil
+}
+
+func parseCommandElement(element string) (*template.Template, error) {
+	tmpl, err := template.New("command").Funcs(commandFuncs).Option("missingkey=error").Parse(element)
+	if err != nil {
+		return nil, fmt.Errorf("invalid command template %q: %w", element, err)
+	}
+	return tmpl, nil
+}
+
+// checkCommandRefs rejects references to inputs the task does not declare
+// and to unknown context fields, and keeps secret inputs out of process
+// arguments.
+func checkCommandRefs(element string, inputs []InputSpec) error {
+	for _, action := range commandAction.FindAllString(element, -1) {
+		for _, ref := range commandRef.FindAllStringSubmatch(action, -1) {
+			switch {
+			case ref[1] == "args" && inputIndex(inputs, ref[2]) < 0:
+				return fmt.Errorf("command %q uses undeclared input %s", element, ref[2])
+			case ref[1] == "args" && inputs[inputIndex(inputs, ref[2])].Secret:
+				return fmt.Errorf("command %q puts secret input %s in process arguments; read $%s instead", element, ref[2], ArgEnvName(ref[2]))
+			case ref[1] == "ctx" && !contains(commandContextKeys, ref[2]):
+				return fmt.Errorf("command %q uses unknown context field %s", element, ref[2])
+			}
+		}
+	}
+	return nil
+}
+
+// shellQuote quotes s for a POSIX shell, or for cmd.exe on Windows.
+func shellQuote(s string) string {
+	if runtime.GOOS == "windows" {
+		return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
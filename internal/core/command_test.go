@@ -0,0 +1,129 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestDirectCommandTemplates(t *testing.T) {
+	task := TaskRecord{
+		PluginID:   "go",
+		PluginPath: "/usr/bin/go",
+		Task: TaskSpec{
+			Name:    "test",
+			Command: []string{"go", "test", "{{.args.pattern}}", "-run={{.args.run}}"},
+			Args:    []string{"{{.args.pkgs}}", "{{.args.tags}}"},
+			Inputs: []InputSpec{
+				{Name: "pattern", Type: "string"},
+				{Name: "run", Type: "string"},
+				{Name: "pkgs", Type: "list"},
+				{Name: "tags", Type: "string"},
+			},
+		},
+	}
+	args := map[string]any{"pattern": "./...", "run": "Foo Bar", "pkgs": []string{"a", "b"}}
+	argv, err := directCommand(task, "/repo", "/repo", "/repo", args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"go", "test", "./...", "-run=Foo Bar", "a", "b"}
+	if !reflect.DeepEqual(argv, want) {
+		t.Fatalf("expected %q, got %q", want, argv)
+	}
+}
+
+func TestDirectCommandDefaultsToExec(t *testing.T) {
+	task := TaskRecord{PluginID: "p", PluginPath: "bin/tool", Task: TaskSpec{Name: "t", Args: []string{"--root={{.ctx.repoRoot}}"}}}
+	argv, err := directCommand(task, "/repo", "/repo", "/repo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"/repo/bin/tool", "--root=/repo"}
+	if runtime.GOOS == "windows" {
+		want[0] = `\repo\bin\tool`
+	}
+	if !reflect.DeepEqual(argv, want) {
+		t.Fatalf("expected %q, got %q", want, argv)
+	}
+}
+
+func TestDirectCommandShellQuotes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("posix quoting")
+	}
+	task := TaskRecord{
+		PluginID: "p",
+		Task: TaskSpec{
+			Name:    "t",
+			Shell:   true,
+			Command: []string{"echo", "{{.args.msg}}", "|", "wc -c", "{{.ctx.repoRoot}}"},
+			Inputs:  []InputSpec{{Name: "msg", Type: "string"}},
+		},
+	}
+	argv, err := directCommand(task, "/my repo/$HOME", "", "", map[string]any{"msg": "it's; rm -rf /"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"sh", "-c", `echo 'it'\''s; rm -rf /' | wc -c '/my repo/$HOME'`}
+	if !reflect.DeepEqual(argv, want) {
+		t.Fatalf("expected %q, got %q", want, argv)
+	}
+}
+
+func TestParseManifestRejectsBadCommandTemplate(t *testing.T) {
+	data := []byte(`{"schemaVersion":1,"plugin":{"id":"p"},"tasks":[{"name":"t","command":["echo","{{.args.x"]}]}`)
+	if _, err := ParseManifest(data); err == nil {
+		t.Fatal("expected error for invalid command template")
+	}
+}
+
+func TestParseManifestRejectsUnknownCommandRefs(t *testing.T) {
+	for _, command := range []string{`"{{.args.tpyo}}"`, `"--root={{.ctx.root}}"`} {
+		data := []byte(`{"schemaVersion":2,"plugin":{"id":"p"},"tasks":[{"name":"t","command":["echo",` + command + `],"inputs":[{"name":"typo","type":"string"}]}]}`)
+		if _, err := ParseManifest(data); err == nil {
+			t.Fatalf("expected error for %s", command)
+		}
+	}
+	task := TaskRecord{PluginID: "p", Task: TaskSpec{Name: "t", Command: []string{"echo", "{{.args.tpyo}}"}}}
+	if _, err := directCommand(task, "", "", "", nil); err == nil {
+		t.Fatal("expected rendering an unknown input to fail instead of passing <no value>")
+	}
+}
+
+func TestDirectCommandKeepsSecretsOutOfArgs(t *testing.T) {
+	data := []byte(`{"schemaVersion":2,"plugin":{"id":"p"},"tasks":[{"name":"t","command":["curl","-H","{{.args.token}}"],"inputs":[{"name":"token","type":"string","secret":true}]}]}`)
+	if _, err := ParseManifest(data); err == nil || !strings.Contains(err.Error(), "AUTOMATE_ME_ARG_TOKEN") {
+		t.Fatalf("expected secret reference to be rejected, got %v", err)
+	}
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping shell command test on windows")
+	}
+	base := t.TempDir()
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	outputFile := filepath.Join(base, "out.txt")
+	task := TaskRecord{
+		PluginID:   "p",
+		DirectExec: true,
+		Task: TaskSpec{
+			Name:    "t",
+			Shell:   true,
+			Command: []string{`echo "$AUTOMATE_ME_ARG_TOKEN" > ` + outputFile},
+			Inputs:  []InputSpec{{Name: "token", Type: "string", Secret: true}},
+		},
+	}
+	if err := RunPluginTask(task, base, base, map[string]any{"token": "s3cret"}); err != nil {
+		t.Fatal(err)
+	}
+	out, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(out)) != "s3cret" {
+		t.Fatalf("expected secret in the environment, got %q", out)
+	}
+}
@@ -0,0 +1,108 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// Config holds user settings from the global config.json, extended by the
+// .automate-me/config.json of every repo level, from the outermost to the
+// repo root.
+type Config struct {
+	Redact RedactConfig `json:"redact"`
+	Env    EnvConfig    `json:"env"`
+	// Providers enables (default) or disables built-in task providers by
+	// plugin id, e.g. {"make": false}.
+	Providers map[string]bool `json:"providers,omitempty"`
+	// Workspace lists the member repos of the workspace, as repo roots or
+	// globs such as "~/projects/*" (see WorkspaceRepos).
+	Workspace []string `json:"workspace,omitempty"`
+}
+
+type RedactConfig struct {
+	// Patterns are regexes whose matches are replaced in task output.
+	Patterns []string `json:"patterns,omitempty"`
+}
+
+func LoadConfig(repoRoot string) (Config, error) {
+	return loadConfig(repoRoot, func(string) bool { return true })
+}
+
+// loadTrustedConfig is LoadConfig without the config of repo levels the
+// user does not trust, for settings a cloned repo must not control, such as
+// the environment of global plugins or the repos of the workspace.
+func loadTrustedConfig(repoRoot string) (Config, error) {
+	store, err := LoadTrustStore()
+	if err != nil {
+		return Config{}, err
+	}
+	return loadConfig(repoRoot, store.trusts)
+}
+
+// loadConfig merges the global config with the config of every repo level
+// include accepts.
+func loadConfig(repoRoot string, include func(levelRoot string) bool) (Config, error) {
+	paths := newPathConfig(repoRoot)
+	var config Config
+
+	globalPath, err := paths.globalConfig()
+	if err != nil {
+		return Config{}, err
+	}
+	if err := mergeConfigFile(&config, globalPath); err != nil {
+		return Config{}, err
+	}
+	levels := RepoLevels(repoRoot)
+	for i := len(levels) - 1; i >= 0; i-- {
+		if !include(levels[i]) {
+			continue
+		}
+		localPath, err := newPathConfig(levels[i]).localConfig()
+		if err != nil {
+			return Config{}, err
+		}
+		if err := mergeConfigFile(&config, localPath); err != nil {
+			return Config{}, err
+		}
+	}
+	return config, nil
+}
+
+func mergeConfigFile(config *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("read config %s: %w", path, err)
+	}
+	var file Config
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	for _, pattern := range file.Redact.Patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("config %s: invalid redact pattern %q: %w", path, pattern, err)
+		}
+	}
+	config.Redact.Patterns = append(config.Redact.Patterns, file.Redact.Patterns...)
+	config.Env.Allow = append(config.Env.Allow, file.Env.Allow...)
+	config.Env.Deny = append(config.Env.Deny, file.Env.Deny...)
+	config.Env.Files = append(config.Env.Files, file.Env.Files...)
+	config.Workspace = append(config.Workspace, file.Workspace...)
+	for key, value := range file.Env.Vars {
+		if config.Env.Vars == nil {
+			config.Env.Vars = make(map[string]string)
+		}
+		config.Env.Vars[key] = value
+	}
+	for id, enabled := range file.Providers {
+		if config.Providers == nil {
+			config.Providers = make(map[string]bool)
+		}
+		config.Providers[id] = enabled
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Severity ranks a diagnostic: errors mean something was dropped (a plugin
+// or spec that could not be loaded), warnings that it was skipped or
+// changed on purpose.
+type Severity string
+
+const (
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+// Diagnostic is a problem found while loading plugins, specs or settings
+// that did not stop the load. Source is the file it is about, if any;
+// Detail is longer output that explains it, such as a plugin's stderr.
+type Diagnostic struct {
+	Severity Severity
+	Source   string
+	Message  string
+	Err      error
+	Detail   string
+}
+
+func (d Diagnostic) String() string {
+	if d.Err == nil {
+		return d.Message
+	}
+	return fmt.Sprintf("%s: %v", d.Message, d.Err)
+}
+
+func (d Diagnostic) MarshalJSON() ([]byte, error) {
+	out := struct {
+		Severity Severity `json:"severity"`
+		Source   string   `json:"source,omitempty"`
+		Message  string   `json:"message"`
+		Error    string   `json:"error,omitempty"`
+		Detail   string   `json:"detail,omitempty"`
+	}{Severity: d.Severity, Source: d.Source, Message: d.Message, Detail: d.Detail}
+	if d.Err != nil {
+		out.Error = d.Err.Error()
+	}
+	return json.Marshal(out)
+}
+
+// Diagnostics collects diagnostics in the order they were found.
+type Diagnostics []Diagnostic
+
+// Warn records a warning about source.
+func (d *Diagnostics) Warn(source, message string, err error) {
+	d.add(Diagnostic{Severity: SeverityWarning, Source: source, Message: message, Err: err})
+}
+
+// Error records an error about source.
+func (d *Diagnostics) Error(source, message string, err error) {
+	d.add(Diagnostic{Severity: SeverityError, Source: source, Message: message, Err: err})
+}
+
+func (d *Diagnostics) add(diagnostic Diagnostic) {
+	*d = append(*d, diagnostic)
+}
+
+// Print writes one "severity: message" line per diagnostic, for CLI modes,
+// followed by its detail indented.
+func (d Diagnostics) Print(writer io.Writer) {
+	for _, diagnostic := range d {
+		fmt.Fprintf(writer, "%s: %s\n", diagnostic.Severity, diagnostic)
+		for _, line := range DetailLines(diagnostic.Detail) {
+			fmt.Fprintf(writer, "  %s\n", line)
+		}
+	}
+}
+
+// DetailLines splits captured output into lines, dropping the trailing
+// newline; it returns nothing for empty output.
+func DetailLines(detail string) []string {
+	detail = strings.TrimRight(detail, "\n")
+	if detail == "" {
+		return nil
+	}
+	return strings.Split(detail, "\n")
+}
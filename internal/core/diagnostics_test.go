@@ -0,0 +1,29 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestDiagnostics(t *testing.T) {
+	var diagnostics Diagnostics
+	diagnostics.Warn("", "plugin id p overridden", nil)
+	diagnostics.Error("/specs/bad.json", "invalid spec /specs/bad.json", errors.New("unexpected EOF"))
+
+	var out bytes.Buffer
+	diagnostics.Print(&out)
+	want := "warning: plugin id p overridden\nerror: invalid spec /specs/bad.json: unexpected EOF\n"
+	if out.String() != want {
+		t.Fatalf("unexpected output:\n%s", out.String())
+	}
+
+	data, err := json.Marshal(diagnostics[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"severity":"error","source":"/specs/bad.json","message":"invalid spec /specs/bad.json","error":"unexpected EOF"}` {
+		t.Fatalf("unexpected JSON: %s", data)
+	}
+}
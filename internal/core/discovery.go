@@ -5,6 +5,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
@@ -13,11 +14,63 @@ type PluginScope string
 const (
 	ScopeLocal  PluginScope = "local"
 	ScopeGlobal PluginScope = "global"
+	// ScopeBuiltin marks tasks from built-in providers, overridden by any
+	// plugin or spec with the same id.
+	ScopeBuiltin PluginScope = "builtin"
 )
 
+// LevelScope returns the scope of plugins from the .automate-me dir depth
+// levels above the repo root (see RepoLevels): ScopeLocal for the repo root
+// itself, then "local:1", "local:2"...
+func LevelScope(depth int) PluginScope {
+	if depth == 0 {
+		return ScopeLocal
+	}
+	return PluginScope(fmt.Sprintf("%s:%d", ScopeLocal, depth))
+}
+
+// Level returns how many levels above the repo root a local scope is; ok is
+// false for global and built-in scopes.
+func (s PluginScope) Level() (int, bool) {
+	if s == ScopeLocal {
+		return 0, true
+	}
+	rest, found := strings.CutPrefix(string(s), string(ScopeLocal)+":")
+	if !found {
+		return 0, false
+	}
+	depth, err := strconv.Atoi(rest)
+	if err != nil || depth < 1 {
+		return 0, false
+	}
+	return depth, true
+}
+
+// IsLocal reports whether s is the scope of a repo's .automate-me dir, at
+// any level.
+func (s PluginScope) IsLocal() bool {
+	_, ok := s.Level()
+	return ok
+}
+
+// scopeRank orders scopes by precedence: built-in providers, global, then
+// local levels from the outermost to the nearest.
+func scopeRank(scope PluginScope) int {
+	switch scope {
+	case ScopeBuiltin:
+		return 0
+	case ScopeGlobal:
+		return 1
+	}
+	depth, _ := scope.Level()
+	return 1<<16 - depth
+}
+
 type pluginCandidate struct {
 	Path  string
 	Scope PluginScope
+	// Root is the directory whose .automate-me dir a local candidate is in.
+	Root string
 }
 
 func FindRepoRoot(start string) (string, bool, error) {
@@ -42,12 +95,48 @@ func FindRepoRoot(start string) (string, bool, error) {
 	return "", false, nil
 }
 
+// OuterRepoRoot returns the top-level root enclosing repoRoot: the nearest
+// directory at or above it that contains .git, or repoRoot itself.
+func OuterRepoRoot(repoRoot string) string {
+	if repoRoot == "" {
+		return ""
+	}
+	for dir := repoRoot; ; {
+		if exists(filepath.Join(dir, gitDirName)) {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return repoRoot
+		}
+		dir = parent
+	}
+}
+
+// RepoLevels returns repoRoot followed by every directory above it, up to
+// its outer repo root, that has a .automate-me dir, nearest first. The
+// index of a level is the depth given to LevelScope.
+func RepoLevels(repoRoot string) []string {
+	if repoRoot == "" {
+		return nil
+	}
+	levels := []string{repoRoot}
+	outer := OuterRepoRoot(repoRoot)
+	for dir := repoRoot; dir != outer; {
+		dir = filepath.Dir(dir)
+		if exists(filepath.Join(dir, localConfigDirName)) {
+			levels = append(levels, dir)
+		}
+	}
+	return levels
+}
+
 func discoverPluginCandidates(repoRoot string) ([]pluginCandidate, error) {
 	paths := newPathConfig(repoRoot)
 
 	var candidates []pluginCandidate
-	if repoRoot != "" {
-		localDir, err := paths.localBin()
+	for depth, root := range RepoLevels(repoRoot) {
+		localDir, err := newPathConfig(root).localBin()
 		if err != nil {
 			return nil, err
 		}
@@ -56,7 +145,7 @@ func discoverPluginCandidates(repoRoot string) ([]pluginCandidate, error) {
 			return nil, err
 		}
 		for _, path := range locals {
-			candidates = append(candidates, pluginCandidate{Path: path, Scope: ScopeLocal})
+			candidates = append(candidates, pluginCandidate{Path: path, Scope: LevelScope(depth), Root: root})
 		}
 	}
 
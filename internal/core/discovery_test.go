@@ -30,6 +30,47 @@ func TestFindRepoRoot(t *testing.T) {
 	}
 }
 
+func TestRepoLevels(t *testing.T) {
+	base := t.TempDir()
+	repo := filepath.Join(base, "repo")
+	pkg := filepath.Join(repo, "packages", "api")
+	for _, dir := range []string{
+		filepath.Join(base, localConfigDirName),
+		filepath.Join(repo, gitDirName),
+		filepath.Join(repo, localConfigDirName),
+		filepath.Join(repo, "packages", "plain"),
+		filepath.Join(pkg, localConfigDirName),
+	} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	root, _, err := FindRepoRoot(pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != pkg {
+		t.Fatalf("expected nearest root %s, got %s", pkg, root)
+	}
+	if outer := OuterRepoRoot(pkg); outer != repo {
+		t.Fatalf("expected outer root %s, got %s", repo, outer)
+	}
+	levels := RepoLevels(pkg)
+	if len(levels) != 2 || levels[0] != pkg || levels[1] != repo {
+		t.Fatalf("expected levels up to the git root, got %v", levels)
+	}
+	if levels := RepoLevels(repo); len(levels) != 1 {
+		t.Fatalf("expected the outer root alone, got %v", levels)
+	}
+	if depth, ok := LevelScope(2).Level(); !ok || depth != 2 || LevelScope(0) != ScopeLocal {
+		t.Fatalf("unexpected level scope %s", LevelScope(2))
+	}
+	if ScopeGlobal.IsLocal() || !LevelScope(1).IsLocal() {
+		t.Fatal("expected only local levels to be local")
+	}
+}
+
 func TestDiscoverPluginCandidates(t *testing.T) {
 	if runtime.GOOS == "windows" {
 		t.Skip("skipping exec bit test on windows")
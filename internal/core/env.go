@@ -0,0 +1,176 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// EnvPolicy filters the variables a task inherits from the caller. Entries
+// are names or globs such as "AWS_*". When any allow list is set only
+// matching variables (plus baselineEnv) are inherited; deny always wins.
+type EnvPolicy struct {
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
+}
+
+type EnvConfig struct {
+	EnvPolicy
+	// Vars are added to every task and Files are .env-style files, relative
+	// to the repo root when not absolute.
+	Vars  map[string]string `json:"vars,omitempty"`
+	Files []string          `json:"files,omitempty"`
+}
+
+// localEnvFileName is loaded from .automate-me when present.
+const localEnvFileName = ".env"
+
+// baselineEnv keeps tasks runnable under an allow list.
+var baselineEnv = []string{"PATH", "HOME", "USER", "SHELL", "TERM", "LANG", "TMPDIR"}
+
+// TaskEnv builds the environment a task receives, later layers winning:
+// inherited variables filtered by config and plugin policies, config vars,
+// env files, plugin env, task env, AUTOMATE_ME_* variables and overrides.
+// Repo config and .env files only apply once the repo is trusted, so a
+// cloned repo cannot set LD_PRELOAD or PATH for global plugins.
+func TaskEnv(task TaskRecord, repoRoot, cwd string, overrides map[string]string) ([]string, error) {
+	config, err := loadTrustedConfig(repoRoot)
+	if err != nil {
+		return nil, err
+	}
+	store, err := LoadTrustStore()
+	if err != nil {
+		return nil, err
+	}
+	policy := config.Env.EnvPolicy
+	if task.PluginInheritEnv != nil {
+		policy.Allow = append(policy.Allow, task.PluginInheritEnv.Allow...)
+		policy.Deny = append(policy.Deny, task.PluginInheritEnv.Deny...)
+	}
+
+	env := make(map[string]string)
+	for _, entry := range os.Environ() {
+		name, value, ok := strings.Cut(entry, "=")
+		if ok && policy.inherits(name) {
+			env[name] = value
+		}
+	}
+	mergeEnv(env, config.Env.Vars)
+
+	files := config.Env.Files
+	if repoRoot != "" && store.trusts(repoRoot) {
+		localRoot, err := newPathConfig(repoRoot).localRoot()
+		if err != nil {
+			return nil, err
+		}
+		if local := filepath.Join(localRoot, localEnvFileName); exists(local) {
+			files = append([]string{local}, files...)
+		}
+	}
+	for _, file := range files {
+		if !filepath.IsAbs(file) && repoRoot != "" {
+			file = filepath.Join(repoRoot, file)
+		}
+		vars, err := readEnvFile(file)
+		if err != nil {
+			return nil, err
+		}
+		mergeEnv(env, vars)
+	}
+
+	mergeEnv(env, task.PluginEnv)
+	mergeEnv(env, task.Task.Env)
+	workdir, err := TaskWorkdir(task, repoRoot, cwd)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range pluginEnv(task, repoRoot, cwd, workdir) {
+		name, value, _ := strings.Cut(entry, "=")
+		env[name] = value
+	}
+	mergeEnv(env, overrides)
+
+	out := make([]string, 0, len(env))
+	for name, value := range env {
+		out = append(out, name+"="+value)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+func (p EnvPolicy) inherits(name string) bool {
+	if matchesEnvPattern(p.Deny, name) {
+		return false
+	}
+	if len(p.Allow) == 0 {
+		return true
+	}
+	return matchesEnvPattern(p.Allow, name) || matchesEnvPattern(baselineEnv, name)
+}
+
+func matchesEnvPattern(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, err := filepath.Match(pattern, name); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+func mergeEnv(env map[string]string, vars map[string]string) {
+	for name, value := range vars {
+		env[name] = value
+	}
+}
+
+// MaskEnv hides the values of variables that look like credentials, for
+// printing an environment.
+func MaskEnv(env []string) []string {
+	out := make([]string, len(env))
+	for i, entry := range env {
+		name, _, ok := strings.Cut(entry, "=")
+		if ok && isSecretEnvName(name) {
+			entry = name + "=" + redactedValue
+		}
+		out[i] = entry
+	}
+	return out
+}
+
+// readEnvFile parses KEY=VALUE lines, skipping blanks and # comments and
+// accepting an optional "export " prefix and quoted values.
+func readEnvFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("read env file: %w", err)
+	}
+	defer file.Close()
+	vars := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		name, value, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("env file %s:%d: expected KEY=VALUE", path, lineNo)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		vars[name] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read env file %s: %w", path, err)
+	}
+	return vars, nil
+}
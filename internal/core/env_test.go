@@ -0,0 +1,115 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTaskEnvLayers(t *testing.T) {
+	base := t.TempDir()
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	os.Setenv("AM_TEST_KEEP", "keep")
+	defer os.Unsetenv("AM_TEST_KEEP")
+	os.Setenv("AM_TEST_DROP", "drop")
+	defer os.Unsetenv("AM_TEST_DROP")
+
+	repo := filepath.Join(base, "repo")
+	localRoot := filepath.Join(repo, localConfigDirName)
+	if err := os.MkdirAll(localRoot, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	config := `{"env":{"deny":["AM_TEST_D*"],"vars":{"FROM_CONFIG":"config","LAYER":"config"}}}`
+	if err := os.WriteFile(filepath.Join(localRoot, configFileName), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dotenv := "# comment\nexport FROM_FILE=\"file value\"\nLAYER=file\n"
+	if err := os.WriteFile(filepath.Join(localRoot, localEnvFileName), []byte(dotenv), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	task := TaskRecord{
+		PluginID:  "p",
+		Task:      TaskSpec{Name: "t", Env: map[string]string{"LAYER": "task"}},
+		PluginEnv: map[string]string{"LAYER": "plugin", "FROM_PLUGIN": "plugin"},
+	}
+	env, err := TaskEnv(task, repo, repo, map[string]string{"FROM_PLUGIN": "override"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := envMap(env)
+	if _, ok := got["FROM_CONFIG"]; ok {
+		t.Fatal("expected config vars of an untrusted repo to be ignored")
+	}
+	if _, ok := got["FROM_FILE"]; ok {
+		t.Fatal("expected .env of an untrusted repo to be ignored")
+	}
+
+	if _, err := TrustRepo(repo); err != nil {
+		t.Fatal(err)
+	}
+	env, err = TaskEnv(task, repo, repo, map[string]string{"FROM_PLUGIN": "override"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = envMap(env)
+	want := map[string]string{
+		"AM_TEST_KEEP":        "keep",
+		"FROM_CONFIG":         "config",
+		"FROM_FILE":           "file value",
+		"LAYER":               "task",
+		"FROM_PLUGIN":         "override",
+		"AUTOMATE_ME_TASK_ID": "p:t",
+	}
+	for name, value := range want {
+		if got[name] != value {
+			t.Fatalf("expected %s=%q, got %q", name, value, got[name])
+		}
+	}
+	if _, ok := got["AM_TEST_DROP"]; ok {
+		t.Fatal("expected denied variable to be dropped")
+	}
+}
+
+func TestTaskEnvPluginAllowList(t *testing.T) {
+	os.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	os.Setenv("AM_TEST_KEEP", "keep")
+	defer os.Unsetenv("AM_TEST_KEEP")
+	os.Setenv("AM_OTHER", "other")
+	defer os.Unsetenv("AM_OTHER")
+
+	task := TaskRecord{PluginID: "p", Task: TaskSpec{Name: "t"}, PluginInheritEnv: &EnvPolicy{Allow: []string{"AM_TEST_*"}}}
+	env, err := TaskEnv(task, "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := envMap(env)
+	if got["AM_TEST_KEEP"] != "keep" {
+		t.Fatal("expected allowed variable")
+	}
+	if _, ok := got["AM_OTHER"]; ok {
+		t.Fatal("expected variable outside the allow list to be dropped")
+	}
+	if _, ok := got["PATH"]; !ok && os.Getenv("PATH") != "" {
+		t.Fatal("expected PATH to be kept under an allow list")
+	}
+}
+
+func TestMaskEnv(t *testing.T) {
+	masked := MaskEnv([]string{"GITHUB_TOKEN=abc", "HOME=/root"})
+	if masked[0] != "GITHUB_TOKEN=****" || masked[1] != "HOME=/root" {
+		t.Fatalf("unexpected masked env: %v", masked)
+	}
+}
+
+func envMap(env []string) map[string]string {
+	out := make(map[string]string)
+	for _, entry := range env {
+		name, value, _ := strings.Cut(entry, "=")
+		out[name] = value
+	}
+	return out
+}
@@ -0,0 +1,298 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateLayout is the format of date inputs, both typed and sent to plugins.
+const DateLayout = "2006-01-02"
+
+// InputActive reports whether an input should be asked, evaluating its
+// when condition against the values collected so far. Every key in when
+// must match; a list matches when any of its entries does.
+func InputActive(input InputSpec, values map[string]any) bool {
+	for name, expected := range input.When {
+		actual, ok := values[name]
+		if !ok || actual == nil {
+			return false
+		}
+		if !conditionMatches(expected, actual) {
+			return false
+		}
+	}
+	return true
+}
+
+func conditionMatches(expected, actual any) bool {
+	if options, ok := expected.([]any); ok {
+		for _, option := range options {
+			if conditionMatches(option, actual) {
+				return true
+			}
+		}
+		return false
+	}
+	if list, ok := actual.([]string); ok {
+		for _, item := range list {
+			if conditionMatches(expected, item) {
+				return true
+			}
+		}
+		return false
+	}
+	return fmt.Sprint(expected) == fmt.Sprint(actual)
+}
+
+// ParseArgs converts raw name=value strings into typed args for a task.
+// Path inputs are resolved against repoRoot or cwd.
+func ParseArgs(inputs []InputSpec, raw map[string]string, repoRoot, cwd string) (map[string]any, error) {
+	args := make(map[string]any, len(raw))
+	for name, value := range raw {
+		input, ok := findInput(inputs, name)
+		if !ok {
+			return nil, fmt.Errorf("unknown input: %s", name)
+		}
+		if input.Secret {
+			return nil, fmt.Errorf("secret input %s cannot be passed as an argument; use secretFrom or %s", name, SecretEnvName(name))
+		}
+		if input.Type == "path" {
+			resolved, err := ResolvePathInput(input, value, repoRoot, cwd)
+			if err != nil {
+				return nil, err
+			}
+			args[name] = resolved
+			continue
+		}
+		parsed, err := ParseInputValue(input, value)
+		if err != nil {
+			return nil, err
+		}
+		args[name] = parsed
+	}
+	return args, nil
+}
+
+// ResolveArgs walks inputs in order without prompting, filling defaults (or
+// resolving secrets) and dropping inputs whose when condition does not hold.
+// It fails on active required inputs that have no value.
+func ResolveArgs(inputs []InputSpec, provided map[string]any, repoRoot, cwd string) (map[string]any, error) {
+	values := make(map[string]any)
+	for _, input := range inputs {
+		if !InputActive(input, values) {
+			continue
+		}
+		value, ok := provided[input.Name]
+		if input.Secret && (!ok || value == nil) {
+			secret, found, err := ResolveSecret(input, repoRoot)
+			if err != nil {
+				return nil, err
+			}
+			if found {
+				value = secret
+			}
+		} else if !ok || value == nil {
+			value = input.Default
+		}
+		value, err := NormalizeValue(input, value)
+		if err != nil {
+			return nil, err
+		}
+		if value == nil {
+			if input.Required {
+				return nil, fmt.Errorf("missing required input: %s", input.Name)
+			}
+			continue
+		}
+		if raw, ok := value.(string); ok && input.Type == "path" {
+			resolved, err := ResolvePathInput(input, raw, repoRoot, cwd)
+			if err != nil {
+				return nil, err
+			}
+			values[input.Name] = resolved
+			continue
+		}
+		if err := ValidateInputValue(input, value); err != nil {
+			return nil, err
+		}
+		values[input.Name] = value
+	}
+	return values, nil
+}
+
+func findInput(inputs []InputSpec, name string) (InputSpec, bool) {
+	for _, input := range inputs {
+		if input.Name == name {
+			return input, true
+		}
+	}
+	return InputSpec{}, false
+}
+
+// ParseInputValue converts a raw string into the value type of input and
+// checks it against the input's validation rules.
+func ParseInputValue(input InputSpec, raw string) (any, error) {
+	value, err := parseInputValue(input, raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateInputValue(input, value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// ParseInputLines parses a list or kv value edited one entry per line, so
+// entries may contain commas. Blank lines are ignored and an empty text
+// yields nil. Other types are parsed like ParseInputValue.
+func ParseInputLines(input InputSpec, text string) (any, error) {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+	var value any
+	switch input.Type {
+	case "list":
+		value = lines
+	case "kv":
+		kv, err := parseKVParts(input, lines)
+		if err != nil {
+			return nil, err
+		}
+		value = kv
+	default:
+		return ParseInputValue(input, strings.TrimSpace(text))
+	}
+	if err := ValidateInputValue(input, value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func parseInputValue(input InputSpec, raw string) (any, error) {
+	switch input.Type {
+	case "string", "path":
+		return raw, nil
+	case "int":
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid int for %s", input.Name)
+		}
+		return value, nil
+	case "float":
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid float for %s", input.Name)
+		}
+		return value, nil
+	case "bool":
+		switch strings.ToLower(raw) {
+		case "true", "t", "yes", "y", "1":
+			return true, nil
+		case "false", "f", "no", "n", "0":
+			return false, nil
+		default:
+			return nil, fmt.Errorf("invalid bool for %s", input.Name)
+		}
+	case "enum":
+		return parseEnum(input, raw)
+	case "text":
+		return raw, nil
+	case "duration":
+		value, err := time.ParseDuration(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration for %s", input.Name)
+		}
+		return value.String(), nil
+	case "date":
+		value, err := time.Parse(DateLayout, raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date for %s (expected %s)", input.Name, DateLayout)
+		}
+		return value.Format(DateLayout), nil
+	case "list":
+		return splitCSV(raw), nil
+	case "kv":
+		return parseKV(input, raw)
+	case "multienum":
+		parts := splitCSV(raw)
+		for _, part := range parts {
+			if len(input.Choices) > 0 && !contains(input.Choices, part) {
+				return nil, fmt.Errorf("invalid choice %q for %s", part, input.Name)
+			}
+		}
+		return parts, nil
+	default:
+		return nil, fmt.Errorf("unsupported input type %q for %s", input.Type, input.Name)
+	}
+}
+
+// NormalizeValue converts defaults written as strings in a manifest (e.g.
+// "90m" for a duration or "a=1,b=2" for kv) into the value type of input.
+func NormalizeValue(input InputSpec, value any) (any, error) {
+	raw, ok := value.(string)
+	if !ok {
+		return value, nil
+	}
+	switch input.Type {
+	case "string", "text", "path", "enum":
+		return value, nil
+	}
+	return parseInputValue(input, raw)
+}
+
+func parseKV(input InputSpec, raw string) (map[string]string, error) {
+	return parseKVParts(input, splitCSV(raw))
+}
+
+func parseKVParts(input InputSpec, parts []string) (map[string]string, error) {
+	out := make(map[string]string)
+	for _, part := range parts {
+		key, value, ok := strings.Cut(part, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid key=value %q for %s", part, input.Name)
+		}
+		out[key] = strings.TrimSpace(value)
+	}
+	return out, nil
+}
+
+func parseEnum(input InputSpec, raw string) (string, error) {
+	if len(input.Choices) == 0 {
+		return raw, nil
+	}
+	if !contains(input.Choices, raw) {
+		return "", fmt.Errorf("invalid choice %q for %s", raw, input.Name)
+	}
+	return raw, nil
+}
+
+func splitCSV(raw string) []string {
+	parts := strings.Split(raw, ",")
+	var out []string
+	for _, part := range parts {
+		p := strings.TrimSpace(part)
+		if p == "" {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+func contains(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
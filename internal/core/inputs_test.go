@@ -0,0 +1,133 @@
+package core
+
+import "testing"
+
+func TestInputActive(t *testing.T) {
+	input := InputSpec{Name: "region", When: map[string]any{"env": []any{"prod", "staging"}, "release": true}}
+	tests := []struct {
+		name   string
+		values map[string]any
+		want   bool
+	}{
+		{name: "match", values: map[string]any{"env": "prod", "release": true}, want: true},
+		{name: "list option", values: map[string]any{"env": "staging", "release": true}, want: true},
+		{name: "mismatch", values: map[string]any{"env": "dev", "release": true}, want: false},
+		{name: "missing", values: map[string]any{"env": "prod"}, want: false},
+	}
+	for _, tt := range tests {
+		if got := InputActive(input, tt.values); got != tt.want {
+			t.Fatalf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+	if !InputActive(InputSpec{Name: "always"}, nil) {
+		t.Fatal("expected input without when to be active")
+	}
+}
+
+func TestResolveArgsSkipsInactiveRequired(t *testing.T) {
+	inputs := []InputSpec{
+		{Name: "release", Type: "bool", Default: false},
+		{Name: "version", Type: "string", Required: true, When: map[string]any{"release": true}},
+	}
+	args, err := ResolveArgs(inputs, nil, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := args["version"]; ok {
+		t.Fatalf("expected version to be skipped, got %v", args)
+	}
+
+	if _, err := ResolveArgs(inputs, map[string]any{"release": true}, "", ""); err == nil {
+		t.Fatal("expected missing version when release is true")
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	inputs := []InputSpec{{Name: "count", Type: "int"}, {Name: "env", Type: "enum", Choices: []string{"dev", "prod"}}}
+	args, err := ParseArgs(inputs, map[string]string{"count": "3", "env": "prod"}, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args["count"] != 3 || args["env"] != "prod" {
+		t.Fatalf("unexpected args: %v", args)
+	}
+	if _, err := ParseArgs(inputs, map[string]string{"other": "x"}, "", ""); err == nil {
+		t.Fatal("expected error for unknown input")
+	}
+	if _, err := ParseArgs(inputs, map[string]string{"env": "qa"}, "", ""); err == nil {
+		t.Fatal("expected error for invalid choice")
+	}
+}
+
+func TestParseInputValueRichTypes(t *testing.T) {
+	duration, err := ParseInputValue(InputSpec{Name: "d", Type: "duration"}, "90m")
+	if err != nil || duration != "1h30m0s" {
+		t.Fatalf("unexpected duration: %v (%v)", duration, err)
+	}
+	date, err := ParseInputValue(InputSpec{Name: "d", Type: "date"}, "2024-02-29")
+	if err != nil || date != "2024-02-29" {
+		t.Fatalf("unexpected date: %v (%v)", date, err)
+	}
+	if _, err := ParseInputValue(InputSpec{Name: "d", Type: "date"}, "29/02/2024"); err == nil {
+		t.Fatal("expected invalid date error")
+	}
+	list, err := ParseInputValue(InputSpec{Name: "l", Type: "list"}, "a, b,,c")
+	if err != nil || len(list.([]string)) != 3 {
+		t.Fatalf("unexpected list: %v (%v)", list, err)
+	}
+	kv, err := ParseInputValue(InputSpec{Name: "kv", Type: "kv"}, "a=1, b = two")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pairs := kv.(map[string]string)
+	if pairs["a"] != "1" || pairs["b"] != "two" {
+		t.Fatalf("unexpected kv: %v", pairs)
+	}
+	if _, err := ParseInputValue(InputSpec{Name: "kv", Type: "kv"}, "novalue"); err == nil {
+		t.Fatal("expected invalid kv error")
+	}
+	text, err := ParseInputValue(InputSpec{Name: "t", Type: "text"}, "line one\nline two")
+	if err != nil || text != "line one\nline two" {
+		t.Fatalf("unexpected text: %v (%v)", text, err)
+	}
+}
+
+func TestResolveArgsNormalizesDefaults(t *testing.T) {
+	inputs := []InputSpec{
+		{Name: "timeout", Type: "duration", Default: "2m"},
+		{Name: "labels", Type: "kv", Default: "team=core"},
+	}
+	args, err := ResolveArgs(inputs, nil, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args["timeout"] != "2m0s" {
+		t.Fatalf("unexpected timeout: %v", args["timeout"])
+	}
+	if args["labels"].(map[string]string)["team"] != "core" {
+		t.Fatalf("unexpected labels: %v", args["labels"])
+	}
+}
+
+func TestParseInputLines(t *testing.T) {
+	list, err := ParseInputLines(InputSpec{Name: "pkgs", Type: "list"}, "a,b\n\n  c \n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := list.([]string); !ok || len(got) != 2 || got[0] != "a,b" || got[1] != "c" {
+		t.Fatalf("expected one entry per line, got %#v", list)
+	}
+	kv, err := ParseInputLines(InputSpec{Name: "labels", Type: "kv"}, "team=a,b\nenv = prod\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := kv.(map[string]string); !ok || got["team"] != "a,b" || got["env"] != "prod" {
+		t.Fatalf("unexpected kv %#v", kv)
+	}
+	if _, err := ParseInputLines(InputSpec{Name: "labels", Type: "kv"}, "broken"); err == nil {
+		t.Fatal("expected error for a line without =")
+	}
+	if value, err := ParseInputLines(InputSpec{Name: "pkgs", Type: "list"}, "\n"); err != nil || value != nil {
+		t.Fatalf("expected empty text to yield nil, got %#v %v", value, err)
+	}
+}
@@ -3,6 +3,9 @@ package core
 import (
 	"encoding/json"
 	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
 )
 
 type Manifest struct {
@@ -12,11 +15,19 @@ type Manifest struct {
 }
 
 type PluginInfo struct {
-	ID       string `json:"id"`
-	Title    string `json:"title"`
-	Version  string `json:"version,omitempty"`
-	Exec     string `json:"exec,omitempty"`
-	ExecMode string `json:"execMode,omitempty"`
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Version string `json:"version,omitempty"`
+	Exec    string `json:"exec,omitempty"`
+	// ExecMode is "direct" (default) or "protocol"; it was execMode before
+	// schemaVersion 2.
+	ExecMode string `json:"mode,omitempty"`
+	// Env is added to the environment of every task of the plugin and
+	// InheritEnv limits which variables of the caller are passed on.
+	Env        map[string]string `json:"env,omitempty"`
+	InheritEnv *EnvPolicy        `json:"inheritEnv,omitempty"`
+	// Workdir is the default working directory of the plugin's tasks.
+	Workdir string `json:"workdir,omitempty"`
 }
 
 type TaskSpec struct {
@@ -25,6 +36,21 @@ type TaskSpec struct {
 	Group       string      `json:"group,omitempty"`
 	Description string      `json:"description,omitempty"`
 	Inputs      []InputSpec `json:"inputs,omitempty"`
+	// Env is added to the environment of this task, after the plugin's.
+	Env map[string]string `json:"env,omitempty"`
+	// Workdir is repoRoot, cwd, pluginDir or a repo-relative path and
+	// overrides the plugin's workdir.
+	Workdir string `json:"workdir,omitempty"`
+	// Command replaces plugin.exec and Args are appended to it, for direct
+	// exec specs. Elements are templates over .args and .ctx; Shell runs the
+	// joined result through the system shell.
+	Command []string `json:"command,omitempty"`
+	Args    []string `json:"args,omitempty"`
+	Shell   bool     `json:"shell,omitempty"`
+	// Script is an inline body run with Interpreter (sh by default) from a
+	// temp file, with inputs exported as AUTOMATE_ME_ARG_<NAME>.
+	Script      string `json:"script,omitempty"`
+	Interpreter string `json:"interpreter,omitempty"`
 }
 
 type InputSpec struct {
@@ -34,27 +60,151 @@ type InputSpec struct {
 	Prompt   string   `json:"prompt"`
 	Default  any      `json:"default,omitempty"`
 	Choices  []string `json:"choices,omitempty"`
-	Secret   bool     `json:"secret,omitempty"`
+	// ChoicesFrom asks the plugin for choices right before prompting, via
+	// `<plugin> choices <task> <choicesFrom>` with the collected args on stdin.
+	ChoicesFrom string `json:"choicesFrom,omitempty"`
+	Secret      bool   `json:"secret,omitempty"`
+	// SecretFrom lets secret inputs resolve without prompting.
+	SecretFrom *SecretSource `json:"secretFrom,omitempty"`
+	// When only asks the input if every named input already collected
+	// matches the given value (or any value of a list).
+	When map[string]any `json:"when,omitempty"`
+
+	// Validation rules, checked on every parsed or defaulted value. They
+	// are encoded under "validate" (see inputRules).
+	Pattern   string   `json:"-"`
+	Min       *float64 `json:"-"`
+	Max       *float64 `json:"-"`
+	MinItems  *int     `json:"-"`
+	MaxItems  *int     `json:"-"`
+	MustExist bool     `json:"-"`
+	Dir       bool     `json:"-"`
+	File      bool     `json:"-"`
+
+	// Path inputs: Base is "repoRoot" (default) or "cwd" and roots both the
+	// picker and relative paths; Relative sends paths relative to Base
+	// instead of absolute. Extensions and Glob filter selectable files.
+	Base       string   `json:"base,omitempty"`
+	Relative   bool     `json:"relative,omitempty"`
+	Extensions []string `json:"extensions,omitempty"`
+	Glob       string   `json:"glob,omitempty"`
+}
+
+// inputRules is the "validate" object of an input, which holds the
+// validation rules since schemaVersion 2.
+type inputRules struct {
+	Pattern   string   `json:"pattern,omitempty"`
+	Min       *float64 `json:"min,omitempty"`
+	Max       *float64 `json:"max,omitempty"`
+	MinItems  *int     `json:"minItems,omitempty"`
+	MaxItems  *int     `json:"maxItems,omitempty"`
+	MustExist bool     `json:"mustExist,omitempty"`
+	Dir       bool     `json:"dir,omitempty"`
+	File      bool     `json:"file,omitempty"`
+}
+
+func (i InputSpec) MarshalJSON() ([]byte, error) {
+	type plain InputSpec
+	rules := inputRules{i.Pattern, i.Min, i.Max, i.MinItems, i.MaxItems, i.MustExist, i.Dir, i.File}
+	out := struct {
+		plain
+		Validate *inputRules `json:"validate,omitempty"`
+	}{plain: plain(i)}
+	if rules != (inputRules{}) {
+		out.Validate = &rules
+	}
+	return json.Marshal(out)
 }
 
+func (i *InputSpec) UnmarshalJSON(data []byte) error {
+	type plain InputSpec
+	var in struct {
+		plain
+		Validate inputRules `json:"validate"`
+	}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*i = InputSpec(in.plain)
+	rules := in.Validate
+	i.Pattern, i.Min, i.Max, i.MinItems, i.MaxItems = rules.Pattern, rules.Min, rules.Max, rules.MinItems, rules.MaxItems
+	i.MustExist, i.Dir, i.File = rules.MustExist, rules.Dir, rules.File
+	return nil
+}
+
+// SecretSource declares where a secret input is read from: an env var, a
+// file, or a key looked up through the secret provider command.
+type SecretSource struct {
+	Env  string `json:"env,omitempty"`
+	File string `json:"file,omitempty"`
+	Key  string `json:"key,omitempty"`
+}
+
+// ParseManifest decodes a JSON manifest of any supported schemaVersion,
+// upgrading older ones to CurrentSchemaVersion, and validates it.
 func ParseManifest(data []byte) (Manifest, error) {
+	data, _, err := upgradeManifest(data)
+	if err != nil {
+		return Manifest{}, err
+	}
 	var m Manifest
 	if err := json.Unmarshal(data, &m); err != nil {
 		return Manifest{}, fmt.Errorf("invalid manifest JSON: %w", err)
 	}
-	if m.SchemaVersion != 1 {
-		return Manifest{}, fmt.Errorf("unsupported schemaVersion: %d", m.SchemaVersion)
-	}
 	if m.Plugin.ID == "" {
 		return Manifest{}, fmt.Errorf("manifest missing plugin.id")
 	}
+	if err := validateWorkdir(m.Plugin.Workdir); err != nil {
+		return Manifest{}, fmt.Errorf("plugin %s: %w", m.Plugin.ID, err)
+	}
 	for i, task := range m.Tasks {
 		if task.Name == "" {
 			return Manifest{}, fmt.Errorf("task[%d] missing name", i)
 		}
+		if err := validateWorkdir(task.Workdir); err != nil {
+			return Manifest{}, fmt.Errorf("task %s: %w", task.Name, err)
+		}
+		if task.Script != "" && (len(task.Command) > 0 || len(task.Args) > 0) {
+			return Manifest{}, fmt.Errorf("task %s: script cannot be combined with command or args", task.Name)
+		}
+		if task.Script != "" && strings.EqualFold(m.Plugin.ExecMode, "protocol") {
+			return Manifest{}, fmt.Errorf("task %s: script is not run in protocol mode", task.Name)
+		}
+		for _, element := range append(append([]string(nil), task.Command...), task.Args...) {
+			if _, err := parseCommandElement(element); err != nil {
+				return Manifest{}, fmt.Errorf("task %s: %w", task.Name, err)
+			}
+			if err := checkCommandRefs(element, task.Inputs); err != nil {
+				return Manifest{}, fmt.Errorf("task %s: %w", task.Name, err)
+			}
+		}
 		if task.Title == "" {
 			m.Tasks[i].Title = task.Name
 		}
+		for _, input := range task.Inputs {
+			if err := validateInputSpec(input); err != nil {
+				return Manifest{}, fmt.Errorf("task %s input %s: %w", task.Name, input.Name, err)
+			}
+		}
 	}
 	return m, nil
 }
+
+func validateInputSpec(input InputSpec) error {
+	if input.Pattern != "" {
+		if _, err := regexp.Compile(input.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	}
+	if input.Glob != "" {
+		if _, err := filepath.Match(input.Glob, ""); err != nil {
+			return fmt.Errorf("invalid glob: %w", err)
+		}
+	}
+	switch input.Base {
+	case "", PathBaseRepoRoot, PathBaseCwd:
+	default:
+		return fmt.Errorf("unknown base %q", input.Base)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"path"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest formats. YAML and TOML manifests are converted to JSON and then
+// decoded and validated exactly like JSON ones.
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+// specExtensions maps spec file extensions to their format.
+var specExtensions = map[string]string{
+	".json": FormatJSON,
+	".yaml": FormatYAML,
+	".yml":  FormatYAML,
+	".toml": FormatTOML,
+}
+
+// ManifestFormat returns the format of a spec file or URL from its
+// extension, or "" if it is not a spec.
+func ManifestFormat(name string) string {
+	if i := strings.IndexAny(name, "?#"); i >= 0 && isSpecURL(name) {
+		name = name[:i]
+	}
+	return specExtensions[strings.ToLower(path.Ext(name))]
+}
+
+// ParseManifestFormat parses a manifest written in format.
+func ParseManifestFormat(data []byte, format string) (Manifest, error) {
+	data, err := manifestJSON(data, format)
+	if err != nil {
+		return Manifest{}, err
+	}
+	return ParseManifest(data)
+}
+
+// ExportSpecFormat is ExportSpec in the given format. YAML and TOML keys
+// are written in alphabetical order.
+func ExportSpecFormat(manifest Manifest, format string) ([]byte, error) {
+	data, err := ExportSpec(manifest)
+	if err != nil || format == FormatJSON || format == "" {
+		return data, err
+	}
+	var value map[string]any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("encode spec: %w", err)
+	}
+	integralNumbers(value)
+	switch format {
+	case FormatYAML:
+		return yaml.Marshal(value)
+	case FormatTOML:
+		var out bytes.Buffer
+		if err := toml.NewEncoder(&out).Encode(value); err != nil {
+			return nil, fmt.Errorf("encode spec: %w", err)
+		}
+		return out.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unknown manifest format %q", format)
+	}
+}
+
+// parseDescribeOutput parses a protocol plugin's describe output: JSON,
+// unless the plugin declares YAML by starting with the "---" document marker.
+func parseDescribeOutput(data []byte) (Manifest, error) {
+	first, _, _ := bytes.Cut(bytes.TrimLeft(data, " \t\r\n"), []byte("\n"))
+	if string(bytes.TrimSpace(first)) == "---" {
+		return ParseManifestFormat(data, FormatYAML)
+	}
+	return ParseManifest(data)
+}
+
+func manifestJSON(data []byte, format string) ([]byte, error) {
+	var value any
+	switch format {
+	case FormatJSON, "":
+		return data, nil
+	case FormatYAML:
+		if err := yaml.Unmarshal(data, &value); err != nil {
+			return nil, fmt.Errorf("invalid manifest YAML: %w", err)
+		}
+	case FormatTOML:
+		if _, err := toml.Decode(string(data), &value); err != nil {
+			return nil, fmt.Errorf("invalid manifest TOML: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown manifest format %q", format)
+	}
+	value, err := jsonValue(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", strings.ToUpper(format), err)
+	}
+	out, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %w", strings.ToUpper(format), err)
+	}
+	return out, nil
+}
+
+// jsonValue converts decoded YAML maps, which may have non-string keys,
+// into values encoding/json accepts.
+func jsonValue(value any) (any, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			converted, err := jsonValue(item)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = converted
+		}
+		return v, nil
+	case map[any]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			name, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("non-string key %v", key)
+			}
+			converted, err := jsonValue(item)
+			if err != nil {
+				return nil, err
+			}
+			out[name] = converted
+		}
+		return out, nil
+	case []any:
+		for i, item := range v {
+			converted, err := jsonValue(item)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = converted
+		}
+		return v, nil
+	case []map[string]any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			converted, err := jsonValue(item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = converted
+		}
+		return out, nil
+	default:
+		return value, nil
+	}
+}
+
+// integralNumbers turns whole float64 values decoded from JSON back into
+// integers, so TOML writes schemaVersion = 1 rather than 1.0.
+func integralNumbers(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			v[key] = integralNumbers(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = integralNumbers(item)
+		}
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return int64(v)
+		}
+	}
+	return value
+}
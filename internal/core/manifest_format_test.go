@@ -0,0 +1,139 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+const yamlSpec = `# Repo tasks, kept in YAML so they can be commented.
+schemaVersion: 1
+plugin:
+  id: repo
+  title: Repo
+  exec: /bin/echo
+tasks:
+  - name: test
+    inputs:
+      - name: count
+        type: int
+        default: 3
+        min: 1
+`
+
+const tomlSpec = `schemaVersion = 1
+
+[plugin]
+id = "repo"
+title = "Repo"
+exec = "/bin/echo"
+
+# One table per task.
+[[tasks]]
+name = "test"
+
+[[tasks.inputs]]
+name = "count"
+type = "int"
+default = 3
+min = 1
+`
+
+func TestParseManifestFormat(t *testing.T) {
+	for format, data := range map[string]string{FormatYAML: yamlSpec, FormatTOML: tomlSpec} {
+		manifest, err := ParseManifestFormat([]byte(data), format)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if manifest.Plugin.ID != "repo" || len(manifest.Tasks) != 1 || manifest.Tasks[0].Title != "test" {
+			t.Fatalf("%s: unexpected manifest %+v", format, manifest)
+		}
+		input := manifest.Tasks[0].Inputs[0]
+		if input.Min == nil || *input.Min != 1 || input.Default != float64(3) {
+			t.Fatalf("%s: unexpected input %+v", format, input)
+		}
+	}
+	if _, err := ParseManifestFormat([]byte("schemaVersion: 1\nplugin: {}\n"), FormatYAML); err == nil {
+		t.Fatal("expected YAML spec to be validated like JSON")
+	}
+}
+
+func TestExportSpecFormatRoundTrip(t *testing.T) {
+	manifest, err := ParseManifestFormat([]byte(yamlSpec), FormatYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, format := range []string{FormatJSON, FormatYAML, FormatTOML} {
+		data, err := ExportSpecFormat(manifest, format)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		again, err := ParseManifestFormat(data, format)
+		if err != nil {
+			t.Fatalf("%s: %v\n%s", format, err, data)
+		}
+		if again.Plugin.Exec != "/bin/echo" || again.Tasks[0].Inputs[0].Name != "count" {
+			t.Fatalf("%s: unexpected round trip %+v", format, again)
+		}
+	}
+}
+
+func TestLoadSpecsReadsYAMLAndTOML(t *testing.T) {
+	base := t.TempDir()
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	repo := filepath.Join(base, "repo")
+	specDir, err := newPathConfig(repo).localSpecs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(specDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(specDir, "a.yml"), []byte(yamlSpec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	other := filepath.Join(base, "b.toml")
+	if err := os.WriteFile(other, []byte(tomlSpec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	specs, _, err := loadSpecs(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(specs) != 1 || specs[0].Manifest.Plugin.ID != "repo" {
+		t.Fatalf("expected the YAML spec, got %+v", specs)
+	}
+
+	// Importing the same id as TOML replaces the YAML file.
+	dest, err := ImportSpecFile(other, repo, ScopeLocal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dest != filepath.Join(specDir, "repo.toml") {
+		t.Fatalf("unexpected destination %s", dest)
+	}
+	if exists(filepath.Join(specDir, "a.yml")) {
+		t.Fatal("expected the replaced YAML spec to be removed")
+	}
+}
+
+func TestDescribePluginYAML(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping shell plugin test on windows")
+	}
+	plugin := filepath.Join(t.TempDir(), "plugin")
+	script := "#!/bin/sh\ncat <<'EOF'\n---\nschemaVersion: 1\nplugin: {id: yml, title: YAML}\ntasks:\n  - name: hello\nEOF\n"
+	if err := os.WriteFile(plugin, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	manifest, _, err := describePlugin(plugin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Plugin.ID != "yml" || manifest.Tasks[0].Name != "hello" {
+		t.Fatalf("unexpected manifest %+v", manifest)
+	}
+}
@@ -0,0 +1,243 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentSchemaVersion is the manifest version Manifest encodes to. Older
+// versions are upgraded when parsed, one migration at a time.
+const CurrentSchemaVersion = 2
+
+// migrations[v] upgrades a decoded manifest document from version v to v+1.
+var migrations = map[int]func(doc map[string]any){
+	1: migrateV1,
+}
+
+// yamlMigrations[v] is migrations[v] applied to a YAML node tree, so that
+// comments and key order survive the rewrite.
+var yamlMigrations = map[int]func(root *yaml.Node){
+	1: migrateYAMLV1,
+}
+
+// migrationSteps[v] describes migrations[v] for specs that must be
+// migrated by hand.
+var migrationSteps = map[int][]string{
+	1: {
+		"rename plugin.execMode to plugin.mode",
+		"move " + strings.Join(v1RuleKeys, ", ") + " of every input into a validate table",
+	},
+}
+
+// v1RuleKeys are the input validation rules that moved under "validate" in
+// version 2.
+var v1RuleKeys = []string{"pattern", "min", "max", "minItems", "maxItems", "mustExist", "dir", "file"}
+
+// ErrManualMigration is returned for specs that cannot be rewritten
+// without losing content and must be migrated by hand.
+var ErrManualMigration = errors.New("migrate by hand")
+
+// migrateV1 renames plugin.execMode to plugin.mode and moves the validation
+// rules of every input under "validate".
+func migrateV1(doc map[string]any) {
+	if plugin, ok := doc["plugin"].(map[string]any); ok {
+		if mode, ok := plugin["execMode"]; ok {
+			plugin["mode"] = mode
+			delete(plugin, "execMode")
+		}
+	}
+	tasks, _ := doc["tasks"].([]any)
+	for _, task := range tasks {
+		task, _ := task.(map[string]any)
+		inputs, _ := task["inputs"].([]any)
+		for _, input := range inputs {
+			input, ok := input.(map[string]any)
+			if !ok {
+				continue
+			}
+			rules := make(map[string]any)
+			for _, key := range v1RuleKeys {
+				if value, ok := input[key]; ok {
+					rules[key] = value
+					delete(input, key)
+				}
+			}
+			if len(rules) > 0 {
+				input["validate"] = rules
+			}
+		}
+	}
+}
+
+// migrateYAMLV1 is migrateV1 on a YAML mapping node.
+func migrateYAMLV1(root *yaml.Node) {
+	if plugin := yamlValue(root, "plugin"); plugin != nil && plugin.Kind == yaml.MappingNode {
+		if i := yamlKeyIndex(plugin, "execMode"); i >= 0 {
+			plugin.Content[i].Value = "mode"
+		}
+	}
+	tasks := yamlValue(root, "tasks")
+	if tasks == nil || tasks.Kind != yaml.SequenceNode {
+		return
+	}
+	for _, task := range tasks.Content {
+		inputs := yamlValue(task, "inputs")
+		if inputs == nil || inputs.Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, input := range inputs.Content {
+			if input.Kind != yaml.MappingNode {
+				continue
+			}
+			rules := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			for _, key := range v1RuleKeys {
+				if i := yamlKeyIndex(input, key); i >= 0 {
+					rules.Content = append(rules.Content, input.Content[i], input.Content[i+1])
+					input.Content = append(input.Content[:i], input.Content[i+2:]...)
+				}
+			}
+			if len(rules.Content) > 0 {
+				key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "validate"}
+				input.Content = append(input.Content, key, rules)
+			}
+		}
+	}
+}
+
+// yamlKeyIndex returns the index of key in a mapping node's content, or -1.
+func yamlKeyIndex(node *yaml.Node, key string) int {
+	if node.Kind != yaml.MappingNode {
+		return -1
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func yamlValue(node *yaml.Node, key string) *yaml.Node {
+	i := yamlKeyIndex(node, key)
+	if i < 0 {
+		return nil
+	}
+	return node.Content[i+1]
+}
+
+// MigrateManifest rewrites a manifest document in format at
+// CurrentSchemaVersion and returns the version it was written in. Unlike
+// ExportSpecFormat it works on the document itself: unknown fields are kept
+// and YAML comments and key order are preserved. TOML cannot be rewritten
+// without losing comments, so it fails with ErrManualMigration and the
+// steps to apply by hand.
+func MigrateManifest(data []byte, format string) ([]byte, int, error) {
+	version, err := ManifestSchemaVersion(data, format)
+	if err != nil || version == CurrentSchemaVersion {
+		return data, version, err
+	}
+	switch format {
+	case FormatJSON, "":
+		upgraded, _, err := upgradeManifest(data)
+		if err != nil {
+			return nil, 0, err
+		}
+		var out bytes.Buffer
+		if err := json.Indent(&out, upgraded, "", "  "); err != nil {
+			return nil, 0, fmt.Errorf("migrate spec: %w", err)
+		}
+		out.WriteByte('\n')
+		return out.Bytes(), version, nil
+	case FormatYAML:
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, 0, fmt.Errorf("invalid manifest YAML: %w", err)
+		}
+		root := doc.Content[0]
+		for v := version; v < CurrentSchemaVersion; v++ {
+			yamlMigrations[v](root)
+		}
+		yamlValue(root, "schemaVersion").Value = strconv.Itoa(CurrentSchemaVersion)
+		var out bytes.Buffer
+		encoder := yaml.NewEncoder(&out)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(&doc); err != nil {
+			return nil, 0, fmt.Errorf("migrate spec: %w", err)
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, 0, fmt.Errorf("migrate spec: %w", err)
+		}
+		return out.Bytes(), version, nil
+	default:
+		steps := []string{fmt.Sprintf("set schemaVersion = %d", CurrentSchemaVersion)}
+		for v := version; v < CurrentSchemaVersion; v++ {
+			steps = append(steps, migrationSteps[v]...)
+		}
+		return nil, version, fmt.Errorf("%w: %s specs are not rewritten, to keep their comments; %s", ErrManualMigration, strings.ToUpper(format), strings.Join(steps, "; "))
+	}
+}
+
+// upgradeManifest returns data migrated to CurrentSchemaVersion and the
+// version it was written in. Current manifests are returned unchanged.
+func upgradeManifest(data []byte) ([]byte, int, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc map[string]any
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, 0, fmt.Errorf("invalid manifest JSON: %w", err)
+	}
+	version, err := schemaVersion(doc)
+	if err != nil {
+		return nil, 0, err
+	}
+	if version == CurrentSchemaVersion {
+		return data, version, nil
+	}
+	for v := version; v < CurrentSchemaVersion; v++ {
+		migrations[v](doc)
+	}
+	doc["schemaVersion"] = CurrentSchemaVersion
+	upgraded, err := json.Marshal(doc)
+	if err != nil {
+		return nil, 0, fmt.Errorf("upgrade manifest: %w", err)
+	}
+	return upgraded, version, nil
+}
+
+func schemaVersion(doc map[string]any) (int, error) {
+	raw, ok := doc["schemaVersion"]
+	if !ok {
+		return 0, fmt.Errorf("manifest missing schemaVersion")
+	}
+	number, ok := raw.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("unsupported schemaVersion: %v", raw)
+	}
+	version, err := number.Int64()
+	if err != nil {
+		return 0, fmt.Errorf("unsupported schemaVersion: %s", number)
+	}
+	switch {
+	case version > CurrentSchemaVersion:
+		return 0, fmt.Errorf("unsupported schemaVersion: %d is newer than this version of automate-me supports (up to %d); upgrade automate-me", version, CurrentSchemaVersion)
+	case version < 1:
+		return 0, fmt.Errorf("unsupported schemaVersion: %d", version)
+	}
+	return int(version), nil
+}
+
+// ManifestSchemaVersion returns the schemaVersion a manifest is written in.
+func ManifestSchemaVersion(data []byte, format string) (int, error) {
+	data, err := manifestJSON(data, format)
+	if err != nil {
+		return 0, err
+	}
+	_, version, err := upgradeManifest(data)
+	return version, err
+}
@@ -0,0 +1,176 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseManifestUpgradesV1(t *testing.T) {
+	v1 := `{"schemaVersion": 1,
+  "plugin": {"id": "p", "exec": "/bin/echo", "execMode": "protocol"},
+  "tasks": [{"name": "t", "inputs": [{"name": "n", "type": "int", "min": 1, "max": 5, "default": 12345678901}]}]}`
+	manifest, err := ParseManifest([]byte(v1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := manifest.Tasks[0].Inputs[0]
+	if manifest.SchemaVersion != CurrentSchemaVersion || manifest.Plugin.ExecMode != "protocol" {
+		t.Fatalf("unexpected upgraded manifest %+v", manifest)
+	}
+	if input.Min == nil || *input.Min != 1 || input.Max == nil || *input.Max != 5 || input.Default != float64(12345678901) {
+		t.Fatalf("unexpected upgraded input %+v", input)
+	}
+
+	v2 := `{"schemaVersion": 2,
+  "plugin": {"id": "p", "exec": "/bin/echo", "mode": "protocol"},
+  "tasks": [{"name": "t", "inputs": [{"name": "n", "type": "int", "validate": {"min": 1, "max": 5}}]}]}`
+	current, err := ParseManifest([]byte(v2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.Plugin.ExecMode != "protocol" || *current.Tasks[0].Inputs[0].Max != 5 {
+		t.Fatalf("unexpected v2 manifest %+v", current)
+	}
+}
+
+func TestParseManifestRejectsFutureVersion(t *testing.T) {
+	_, err := ParseManifest([]byte(`{"schemaVersion": 9, "plugin": {"id": "p"}}`))
+	if err == nil || !strings.Contains(err.Error(), "upgrade automate-me") {
+		t.Fatalf("expected a future version error, got %v", err)
+	}
+	if _, err := ParseManifest([]byte(`{"plugin": {"id": "p"}}`)); err == nil {
+		t.Fatal("expected missing schemaVersion to be rejected")
+	}
+}
+
+func TestExportSpecWritesCurrentVersion(t *testing.T) {
+	min := 1.0
+	data, err := ExportSpec(Manifest{
+		SchemaVersion: 1,
+		Plugin:        PluginInfo{ID: "p", ExecMode: "protocol"},
+		Tasks:         []TaskSpec{{Name: "t", Inputs: []InputSpec{{Name: "n", Type: "int", Min: &min}}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(data)
+	if !strings.Contains(out, `"schemaVersion": 2`) || !strings.Contains(out, `"mode": "protocol"`) || !strings.Contains(out, `"validate": {`) {
+		t.Fatalf("unexpected export %s", out)
+	}
+	if strings.Contains(out, "execMode") {
+		t.Fatalf("expected no v1 fields in export %s", out)
+	}
+}
+
+func TestMigrateStoredSpecKeepsFormat(t *testing.T) {
+	base := t.TempDir()
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	repo := filepath.Join(base, "repo")
+	specDir, err := newPathConfig(repo).localSpecs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(specDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(specDir, "repo.yaml")
+	if err := os.WriteFile(path, []byte(yamlSpec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	spec, err := FindStoredSpec(repo, "repo", ScopeLocal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	from, err := MigrateStoredSpec(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != 1 {
+		t.Fatalf("expected migration from 1, got %d", from)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version, err := ManifestSchemaVersion(data, FormatYAML); err != nil || version != CurrentSchemaVersion {
+		t.Fatalf("expected migrated YAML at version %d, got %d (%v):\n%s", CurrentSchemaVersion, version, err, data)
+	}
+	if !strings.Contains(string(data), "validate:") {
+		t.Fatalf("expected rules under validate:\n%s", data)
+	}
+	if !strings.Contains(string(data), "# Repo tasks, kept in YAML") || strings.Index(string(data), "id: repo") > strings.Index(string(data), "title: Repo") {
+		t.Fatalf("expected comments and key order to be kept:\n%s", data)
+	}
+	if from, err := MigrateStoredSpec(spec); err != nil || from != CurrentSchemaVersion {
+		t.Fatalf("expected current spec to be left alone, got %d %v", from, err)
+	}
+}
+
+func TestMigrateStoredSpecKeepsSourceAndRefusesTOML(t *testing.T) {
+	base := t.TempDir()
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	repo := filepath.Join(base, "repo")
+	specDir, err := newPathConfig(repo).localSpecs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(specDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	source := filepath.Join(base, "repo.yaml")
+	if err := os.WriteFile(source, []byte(yamlSpec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	plan, err := PlanSpecImport(source, repo, ScopeLocal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plan.Write(); err != nil {
+		t.Fatal(err)
+	}
+	spec, err := FindStoredSpec(repo, "repo", ScopeLocal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := MigrateStoredSpec(spec); err != nil {
+		t.Fatal(err)
+	}
+	sources, err := readSpecSources(specDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(yamlSpec))
+	if sources[filepath.Base(spec.Path)].SHA256 != hex.EncodeToString(sum[:]) {
+		t.Fatalf("expected recorded sha to stay the source's, got %+v", sources)
+	}
+	updates, failed, err := SpecUpdates(repo, ScopeLocal)
+	if err != nil || len(failed) > 0 {
+		t.Fatalf("unexpected update error %v %v", err, failed)
+	}
+	if len(updates) != 0 {
+		t.Fatalf("expected no updates after migrating an unchanged source, got %+v", updates)
+	}
+
+	tomlPath := filepath.Join(specDir, "other.toml")
+	if err := os.WriteFile(tomlPath, []byte(strings.Replace(tomlSpec, `id = "repo"`, `id = "other"`, 1)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	spec, err = FindStoredSpec(repo, "other", ScopeLocal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := MigrateStoredSpec(spec); !errors.Is(err, ErrManualMigration) || !strings.Contains(err.Error(), "validate") {
+		t.Fatalf("expected manual migration error, got %v", err)
+	}
+	if data, _ := os.ReadFile(tomlPath); !strings.Contains(string(data), "# One table per task.") {
+		t.Fatalf("expected TOML spec to be left alone:\n%s", data)
+	}
+}
@@ -13,7 +13,7 @@ func TestParseManifestErrors(t *testing.T) {
 		},
 		{
 			name: "unsupported schema",
-			json: `{"schemaVersion":2,"plugin":{"id":"p"},"tasks":[]}`,
+			json: `{"schemaVersion":3,"plugin":{"id":"p"},"tasks":[]}`,
 		},
 		{
 			name: "missing plugin id",
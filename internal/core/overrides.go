@@ -0,0 +1,210 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Overrides adjusts tasks of a repo without touching the plugins that
+// provide them, typically global plugins shared with other repos. It is
+// read from the repo's .automate-me/overrides.json.
+type Overrides struct {
+	// Tasks maps task ids ("plugin:task") to their override.
+	Tasks map[string]TaskOverride `json:"tasks"`
+	// Path is the file the overrides were read from.
+	Path string `json:"-"`
+	// Untrusted is set when Path exists but the repo is not trusted, so its
+	// overrides were not read.
+	Untrusted bool `json:"-"`
+}
+
+// TaskOverride replaces parts of one task. Empty fields are left as the
+// plugin declared them.
+type TaskOverride struct {
+	Hidden      bool   `json:"hidden,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Group       string `json:"group,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Defaults replaces the default of inputs, which are still asked.
+	Defaults map[string]any `json:"defaults,omitempty"`
+	// Values presets inputs: they are not asked and --arg is the only way
+	// to change them.
+	Values map[string]any `json:"values,omitempty"`
+}
+
+// LoadOverrides reads the repo's overrides file. A missing file, or no
+// repo, yields no overrides. The file is only read once the repo is
+// trusted; until then the result is marked Untrusted.
+func LoadOverrides(repoRoot string) (Overrides, error) {
+	if repoRoot == "" {
+		return Overrides{}, nil
+	}
+	path, err := newPathConfig(repoRoot).localOverrides()
+	if err != nil {
+		return Overrides{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Overrides{}, nil
+		}
+		return Overrides{}, fmt.Errorf("read overrides %s: %w", path, err)
+	}
+	store, err := LoadTrustStore()
+	if err != nil {
+		return Overrides{}, err
+	}
+	if !store.trusts(repoRoot) {
+		return Overrides{Path: path, Untrusted: true}, nil
+	}
+	var overrides Overrides
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return Overrides{}, fmt.Errorf("invalid overrides %s: %w", path, err)
+	}
+	overrides.Path = path
+	return overrides, nil
+}
+
+// ApplyOverrides returns tasks with overrides applied and hidden tasks
+// dropped, plus diagnostics about overrides that match no task or input.
+func ApplyOverrides(tasks []TaskRecord, overrides Overrides) ([]TaskRecord, Diagnostics) {
+	if overrides.Untrusted {
+		var diagnostics Diagnostics
+		diagnostics.Warn(overrides.Path, "overrides not applied: repo is not trusted (run `automate-me trust`)", nil)
+		return tasks, diagnostics
+	}
+	if len(overrides.Tasks) == 0 {
+		return tasks, nil
+	}
+	var diagnostics Diagnostics
+	matched := make(map[string]bool)
+	out := make([]TaskRecord, 0, len(tasks))
+	for _, task := range tasks {
+		id := TaskID(task.PluginID, task.Task.Name)
+		override, ok := overrides.Tasks[id]
+		if !ok {
+			out = append(out, task)
+			continue
+		}
+		matched[id] = true
+		if override.Hidden {
+			continue
+		}
+		if override.Title != "" {
+			task.Task.Title = override.Title
+		}
+		if override.Group != "" {
+			task.Task.Group = override.Group
+		}
+		if override.Description != "" {
+			task.Task.Description = override.Description
+		}
+		// Copy the inputs so the plugin's manifest is left untouched.
+		task.Task.Inputs = append([]InputSpec(nil), task.Task.Inputs...)
+		for _, name := range sortedKeys(override.Defaults) {
+			i := inputIndex(task.Task.Inputs, name)
+			if i < 0 {
+				diagnostics.Warn(overrides.Path, fmt.Sprintf("override for %s: unknown input %s", id, name), nil)
+				continue
+			}
+			task.Task.Inputs[i].Default = override.Defaults[name]
+		}
+		for _, name := range sortedKeys(override.Values) {
+			i := inputIndex(task.Task.Inputs, name)
+			switch {
+			case i < 0:
+				diagnostics.Warn(overrides.Path, fmt.Sprintf("override for %s: unknown input %s", id, name), nil)
+				continue
+			case task.Task.Inputs[i].Secret:
+				diagnostics.Warn(overrides.Path, fmt.Sprintf("override for %s: secret input %s cannot be preset", id, name), nil)
+				continue
+			}
+			if task.Presets == nil {
+				task.Presets = make(map[string]any)
+			}
+			task.Presets[name] = override.Values[name]
+		}
+		out = append(out, task)
+	}
+	for _, id := range sortedKeys(overrides.Tasks) {
+		if !matched[id] {
+			diagnostics.Warn(overrides.Path, fmt.Sprintf("override for unknown task %s", id), nil)
+		}
+	}
+	return out, diagnostics
+}
+
+// PresetArgs returns the task's preset values typed, resolved and validated
+// like arguments, leaving out inputs named in provided.
+func PresetArgs(task TaskRecord, provided map[string]any, repoRoot, cwd string) (map[string]any, error) {
+	args := make(map[string]any, len(task.Presets))
+	for name, preset := range task.Presets {
+		if _, ok := provided[name]; ok {
+			continue
+		}
+		input, ok := findInput(task.Task.Inputs, name)
+		if !ok {
+			return nil, fmt.Errorf("unknown input: %s", name)
+		}
+		value := presetValue(preset)
+		if raw, ok := value.(string); ok {
+			parsed, err := parseInputValue(input, raw)
+			if err != nil {
+				return nil, fmt.Errorf("preset %s: %w", name, err)
+			}
+			value = parsed
+		}
+		if raw, ok := value.(string); ok && input.Type == "path" {
+			resolved, err := ResolvePathInput(input, raw, repoRoot, cwd)
+			if err != nil {
+				return nil, fmt.Errorf("preset %s: %w", name, err)
+			}
+			value = resolved
+		}
+		if err := ValidateInputValue(input, value); err != nil {
+			return nil, fmt.Errorf("preset %s: %w", name, err)
+		}
+		args[name] = value
+	}
+	return args, nil
+}
+
+// presetValue converts JSON lists and objects into the []string and
+// map[string]string values prompts produce.
+func presetValue(value any) any {
+	switch v := value.(type) {
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = fmt.Sprint(item)
+		}
+		return items
+	case map[string]any:
+		pairs := make(map[string]string, len(v))
+		for key, item := range v {
+			pairs[key] = fmt.Sprint(item)
+		}
+		return pairs
+	}
+	return value
+}
+
+func inputIndex(inputs []InputSpec, name string) int {
+	for i, input := range inputs {
+		if input.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,133 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestApplyOverrides(t *testing.T) {
+	inputs := []InputSpec{
+		{Name: "env", Type: "enum", Choices: []string{"dev", "prod"}, Default: "dev"},
+		{Name: "tags", Type: "list"},
+		{Name: "token", Type: "string", Secret: true},
+	}
+	tasks := []TaskRecord{
+		{PluginID: "deploy", Task: TaskSpec{Name: "run", Title: "Run", Group: "ops", Inputs: inputs}},
+		{PluginID: "deploy", Task: TaskSpec{Name: "rollback", Title: "Rollback"}},
+		{PluginID: "build", Task: TaskSpec{Name: "all", Title: "All"}},
+	}
+	overrides := Overrides{Tasks: map[string]TaskOverride{
+		"deploy:run": {
+			Title:    "Deploy",
+			Group:    "release",
+			Defaults: map[string]any{"env": "prod", "missing": 1},
+			Values:   map[string]any{"tags": []any{"a", "b"}, "token": "x"},
+		},
+		"deploy:rollback": {Hidden: true},
+		"gone:task":       {Hidden: true},
+	}}
+
+	got, diagnostics := ApplyOverrides(tasks, overrides)
+	if len(got) != 2 {
+		t.Fatalf("expected hidden task dropped, got %d tasks", len(got))
+	}
+	run := got[0]
+	if run.Task.Title != "Deploy" || run.Task.Group != "release" {
+		t.Fatalf("unexpected title/group: %q %q", run.Task.Title, run.Task.Group)
+	}
+	if run.Task.Inputs[0].Default != "prod" {
+		t.Fatalf("expected default prod, got %v", run.Task.Inputs[0].Default)
+	}
+	if inputs[0].Default != "dev" {
+		t.Fatal("expected plugin inputs to be left untouched")
+	}
+	if _, ok := run.Presets["token"]; ok {
+		t.Fatal("expected secret preset to be refused")
+	}
+	want := []string{
+		"override for deploy:run: unknown input missing",
+		"override for deploy:run: secret input token cannot be preset",
+		"override for unknown task gone:task",
+	}
+	var messages []string
+	for _, diagnostic := range diagnostics {
+		messages = append(messages, diagnostic.String())
+	}
+	if !reflect.DeepEqual(messages, want) {
+		t.Fatalf("unexpected diagnostics: %q", messages)
+	}
+
+	args, err := PresetArgs(run, nil, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(args, map[string]any{"tags": []string{"a", "b"}}) {
+		t.Fatalf("unexpected preset args: %#v", args)
+	}
+	args, err = PresetArgs(run, map[string]any{"tags": []string{"c"}}, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(args) != 0 {
+		t.Fatalf("expected provided args to replace presets, got %#v", args)
+	}
+}
+
+func TestPresetArgsValidates(t *testing.T) {
+	task := TaskRecord{
+		PluginID: "p",
+		Task:     TaskSpec{Name: "t", Inputs: []InputSpec{{Name: "env", Type: "enum", Choices: []string{"dev"}}}},
+		Presets:  map[string]any{"env": "prod"},
+	}
+	if _, err := PresetArgs(task, nil, "", ""); err == nil {
+		t.Fatal("expected invalid preset to fail")
+	}
+}
+
+func TestLoadOverrides(t *testing.T) {
+	base := t.TempDir()
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	repo := filepath.Join(base, "repo")
+	overrides, err := LoadOverrides(repo)
+	if err != nil || len(overrides.Tasks) != 0 {
+		t.Fatalf("expected no overrides without a file, got %v %v", overrides, err)
+	}
+	dir := filepath.Join(repo, localConfigDirName)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, overridesFileName)
+	if err := os.WriteFile(path, []byte(`{"tasks": {"p:t": {"hidden": true}}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	overrides, err = LoadOverrides(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !overrides.Untrusted || len(overrides.Tasks) != 0 {
+		t.Fatalf("expected overrides of an untrusted repo to be skipped, got %#v", overrides)
+	}
+	tasks, diagnostics := ApplyOverrides([]TaskRecord{{PluginID: "p", Task: TaskSpec{Name: "t"}}}, overrides)
+	if len(tasks) != 1 || len(diagnostics) != 1 || diagnostics[0].Source != path {
+		t.Fatalf("expected task kept and a warning, got %v %v", tasks, diagnostics)
+	}
+	if _, err := TrustRepo(repo); err != nil {
+		t.Fatal(err)
+	}
+	overrides, err = LoadOverrides(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !overrides.Tasks["p:t"].Hidden {
+		t.Fatalf("expected p:t hidden, got %#v", overrides)
+	}
+	if err := os.WriteFile(path, []byte(`{`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadOverrides(repo); err == nil {
+		t.Fatal("expected invalid overrides to fail")
+	}
+}
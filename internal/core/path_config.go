@@ -11,6 +11,9 @@ const (
 	gitDirName          = ".git"
 	binDirName          = "bin"
 	specsDirName        = "specs"
+	configFileName      = "config.json"
+	trustFileName       = "trust.json"
+	overridesFileName   = "overrides.json"
 )
 
 type pathConfig struct {
@@ -44,6 +47,22 @@ func (p pathConfig) localSpecs() (string, error) {
 	return filepath.Join(root, specsDirName), nil
 }
 
+func (p pathConfig) localConfig() (string, error) {
+	root, err := p.localRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, configFileName), nil
+}
+
+func (p pathConfig) localOverrides() (string, error) {
+	root, err := p.localRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, overridesFileName), nil
+}
+
 func (p pathConfig) globalRoot() (string, error) {
 	configDir, err := configBaseDir()
 	if err != nil {
@@ -67,3 +86,19 @@ func (p pathConfig) globalSpecs() (string, error) {
 	}
 	return filepath.Join(root, specsDirName), nil
 }
+
+func (p pathConfig) globalConfig() (string, error) {
+	root, err := p.globalRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, configFileName), nil
+}
+
+func (p pathConfig) globalTrust() (string, error) {
+	root, err := p.globalRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, trustFileName), nil
+}
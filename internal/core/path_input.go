@@ -0,0 +1,90 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	PathBaseRepoRoot = "repoRoot"
+	PathBaseCwd      = "cwd"
+)
+
+// PathBase returns the directory relative path inputs are resolved against.
+func PathBase(input InputSpec, repoRoot, cwd string) string {
+	if input.Base == PathBaseCwd || repoRoot == "" {
+		return cwd
+	}
+	return repoRoot
+}
+
+// ResolvePathInput resolves a typed path against the input's base, validates
+// it and returns it absolute, or relative to the base when input.Relative is
+// set. Resolving an already resolved value returns it unchanged.
+func ResolvePathInput(input InputSpec, raw, repoRoot, cwd string) (string, error) {
+	base := PathBase(input, repoRoot, cwd)
+	path := expandHome(raw)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(base, path)
+	}
+	path = filepath.Clean(path)
+	if err := ValidateInputValue(input, path); err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(path); !input.Dir && (err != nil || !info.IsDir()) && !MatchesPathFilter(input, path) {
+		return "", fmt.Errorf("%s does not match the allowed files: %s", input.Name, raw)
+	}
+	if !input.Relative {
+		return path, nil
+	}
+	rel, err := filepath.Rel(base, path)
+	if err != nil {
+		return "", fmt.Errorf("resolve %s relative to %s: %w", input.Name, base, err)
+	}
+	return rel, nil
+}
+
+// MatchesPathFilter reports whether a file name passes the input's
+// extensions and glob filters.
+func MatchesPathFilter(input InputSpec, path string) bool {
+	name := filepath.Base(path)
+	if len(input.Extensions) > 0 {
+		matched := false
+		for _, ext := range input.Extensions {
+			if strings.EqualFold(filepath.Ext(name), normalizeExt(ext)) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if input.Glob != "" {
+		ok, err := filepath.Match(input.Glob, name)
+		if err != nil || !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func normalizeExt(ext string) string {
+	if strings.HasPrefix(ext, ".") {
+		return ext
+	}
+	return "." + ext
+}
+
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
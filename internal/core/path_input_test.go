@@ -0,0 +1,52 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolvePathInput(t *testing.T) {
+	base := t.TempDir()
+	repo := filepath.Join(base, "repo")
+	cwd := filepath.Join(repo, "sub")
+	if err := os.MkdirAll(cwd, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "spec.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	abs, err := ResolvePathInput(InputSpec{Name: "p", Type: "path", MustExist: true}, "spec.json", repo, cwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if abs != filepath.Join(repo, "spec.json") {
+		t.Fatalf("expected path resolved against repo root, got %s", abs)
+	}
+
+	rel, err := ResolvePathInput(InputSpec{Name: "p", Type: "path", Base: PathBaseCwd, Relative: true}, "../spec.json", repo, cwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rel != filepath.Join("..", "spec.json") {
+		t.Fatalf("expected path relative to cwd, got %s", rel)
+	}
+	again, err := ResolvePathInput(InputSpec{Name: "p", Type: "path", Base: PathBaseCwd, Relative: true}, rel, repo, cwd)
+	if err != nil || again != rel {
+		t.Fatalf("expected resolving twice to be stable, got %s (%v)", again, err)
+	}
+
+	if _, err := ResolvePathInput(InputSpec{Name: "p", Type: "path", Extensions: []string{".yaml"}}, "spec.json", repo, cwd); err == nil {
+		t.Fatal("expected extension filter error")
+	}
+	if _, err := ResolvePathInput(InputSpec{Name: "p", Type: "path", Glob: "*.json"}, "spec.json", repo, cwd); err != nil {
+		t.Fatalf("expected glob to match: %v", err)
+	}
+	if _, err := ResolvePathInput(InputSpec{Name: "p", Type: "path", Extensions: []string{".yaml"}}, "new.json", repo, cwd); err == nil {
+		t.Fatal("expected extension filter error for a file that does not exist yet")
+	}
+	if _, err := ResolvePathInput(InputSpec{Name: "p", Type: "path", Extensions: []string{".yaml"}}, "new.yaml", repo, cwd); err != nil {
+		t.Fatalf("expected new file matching the filter to be accepted: %v", err)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"os"
 	"os/exec"
 )
 
@@ -13,71 +12,154 @@ type PluginRecord struct {
 	Scope      PluginScope
 	Manifest   Manifest
 	DirectExec bool
+	// SpecPath is the spec file a record was loaded from, empty for
+	// protocol plugins discovered in a bin dir.
+	SpecPath string
+	// DescribeStderr is what a protocol plugin's describe wrote on stderr,
+	// at most describeStderrLimit bytes.
+	DescribeStderr string
+	// Root is the directory whose .automate-me dir a local plugin or spec
+	// comes from: the repo root or one of its outer levels.
+	Root string
 }
 
 type TaskRecord struct {
-	PluginID    string
-	PluginTitle string
-	Task        TaskSpec
-	Scope       PluginScope
-	PluginPath  string
-	DirectExec  bool
+	PluginID         string
+	PluginTitle      string
+	Task             TaskSpec
+	Scope            PluginScope
+	PluginPath       string
+	DirectExec       bool
+	PluginEnv        map[string]string
+	PluginInheritEnv *EnvPolicy
+	PluginWorkdir    string
+	// SpecPath is set for tasks loaded from a spec file.
+	SpecPath string
+	// Presets are input values fixed by the repo's overrides, which are
+	// not asked (see ApplyOverrides).
+	Presets map[string]any
+	// DescribeStderr is the plugin's PluginRecord.DescribeStderr.
+	DescribeStderr string
+	// LevelRoot is the plugin's PluginRecord.Root; relative commands and
+	// workdirs of local specs resolve against it.
+	LevelRoot string
+	// Repo names the workspace member repo the task comes from, and
+	// RepoRoot is its root; both are empty outside workspace mode.
+	Repo     string
+	RepoRoot string
 }
 
-func LoadPlugins(repoRoot string) ([]PluginRecord, error) {
+// ID returns the task id users type: plugin:task, prefixed with the
+// workspace repo name as repo/plugin:task in workspace mode.
+func (t TaskRecord) ID() string {
+	if t.Repo != "" {
+		return t.Repo + "/" + TaskID(t.PluginID, t.Task.Name)
+	}
+	return TaskID(t.PluginID, t.Task.Name)
+}
+
+// LoadPlugins returns the plugins of repoRoot, one per plugin id, with
+// diagnostics about the candidates that could not be loaded or were
+// overridden.
+func LoadPlugins(repoRoot string) ([]PluginRecord, Diagnostics, error) {
+	plugins, explanation, err := resolvePlugins(repoRoot, true)
+	return plugins, explanation.Diagnostics, err
+}
+
+// LoadLocalPlugins is LoadPlugins without global plugins and specs, for
+// loading more repos once the global ones were loaded: global protocol
+// plugins are not described again for each of them.
+func LoadLocalPlugins(repoRoot string) ([]PluginRecord, Diagnostics, error) {
+	plugins, explanation, err := resolvePlugins(repoRoot, false)
+	return plugins, explanation.Diagnostics, err
+}
+
+// ExplainPlugins reports every plugin candidate of repoRoot and how
+// LoadPlugins chose between them.
+func ExplainPlugins(repoRoot string) (PluginExplanation, error) {
+	_, explanation, err := resolvePlugins(repoRoot, true)
+	return explanation, err
+}
+
+func resolvePlugins(repoRoot string, global bool) ([]PluginRecord, PluginExplanation, error) {
 	candidates, err := discoverPluginCandidates(repoRoot)
 	if err != nil {
-		return nil, err
+		return nil, PluginExplanation{}, err
 	}
-	specs, err := loadSpecs(repoRoot)
+	specs, specDiagnostics, err := loadSpecs(repoRoot)
 	if err != nil {
-		return nil, err
+		return nil, PluginExplanation{}, err
+	}
+	providers, providerDiagnostics, err := loadProviders(repoRoot)
+	if err != nil {
+		return nil, PluginExplanation{}, err
+	}
+	trust, err := LoadTrustStore()
+	if err != nil {
+		return nil, PluginExplanation{}, err
+	}
+	resolver := newPluginResolver()
+	resolver.diagnostics = append(providerDiagnostics, specDiagnostics...)
+	for _, provider := range providers {
+		resolver.add(provider, KindProvider)
 	}
-	byID := make(map[string]PluginRecord)
 	for _, candidate := range candidates {
-		manifest, err := describePlugin(candidate.Path)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "warning: %s describe failed: %v\n", candidate.Path, err)
+		if !global && candidate.Scope == ScopeGlobal {
 			continue
 		}
-		record := PluginRecord{Path: candidate.Path, Scope: candidate.Scope, Manifest: manifest, DirectExec: false}
-		if existing, ok := byID[manifest.Plugin.ID]; ok {
-			if existing.Scope == ScopeLocal && candidate.Scope == ScopeGlobal {
-				continue
+		if candidate.Scope.IsLocal() {
+			reason, err := trust.untrustedReason(candidate.Root, candidate.Path)
+			if err != nil {
+				return nil, PluginExplanation{}, err
 			}
-			if existing.Scope == ScopeGlobal && candidate.Scope == ScopeLocal {
-				fmt.Fprintf(os.Stderr, "warning: plugin id %s overridden by local %s (was %s)\n", manifest.Plugin.ID, candidate.Path, existing.Path)
+			if reason != "" {
+				resolver.skip(PluginCandidate{Kind: KindProtocol, Scope: candidate.Scope, Path: candidate.Path, Status: CandidateUntrusted, Reason: reason})
+				continue
 			}
 		}
-		byID[manifest.Plugin.ID] = record
+		manifest, stderr, err := describePlugin(candidate.Path)
+		if err != nil {
+			resolver.diagnostics.add(Diagnostic{Severity: SeverityError, Source: candidate.Path, Message: candidate.Path + " describe failed", Err: err, Detail: stderr})
+			resolver.skip(PluginCandidate{Kind: KindProtocol, Scope: candidate.Scope, Path: candidate.Path, Status: CandidateFailed, Reason: err.Error(), Stderr: stderr})
+			continue
+		}
+		resolver.add(PluginRecord{Path: candidate.Path, Scope: candidate.Scope, Manifest: manifest, DirectExec: false, DescribeStderr: stderr, Root: candidate.Root}, KindProtocol)
 	}
 	for _, spec := range specs {
-		if existing, ok := byID[spec.Manifest.Plugin.ID]; ok {
-			if existing.Scope == ScopeLocal && spec.Scope == ScopeGlobal {
-				continue
+		if !global && spec.Scope == ScopeGlobal {
+			continue
+		}
+		if spec.Scope.IsLocal() {
+			reason, err := trust.specExecReason(spec.Root, spec)
+			if err != nil {
+				return nil, PluginExplanation{}, err
 			}
-			if existing.Scope == ScopeGlobal && spec.Scope == ScopeLocal {
-				fmt.Fprintf(os.Stderr, "warning: plugin id %s overridden by local spec %s (was %s)\n", spec.Manifest.Plugin.ID, spec.Path, existing.Path)
+			if reason != "" {
+				resolver.skip(PluginCandidate{ID: spec.Manifest.Plugin.ID, Kind: KindSpec, Scope: spec.Scope, Path: spec.SpecPath, Status: CandidateUntrusted, Reason: reason})
+				continue
 			}
 		}
-		byID[spec.Manifest.Plugin.ID] = spec
-	}
-	var plugins []PluginRecord
-	for _, plugin := range byID {
-		plugins = append(plugins, plugin)
+		resolver.add(spec, KindSpec)
 	}
-	return plugins, nil
+	return resolver.plugins(), resolver.explanation(), nil
 }
 
-func describePlugin(path string) (Manifest, error) {
+// describeStderrLimit bounds the stderr kept from a plugin's describe.
+const describeStderrLimit = 16 << 10
+
+// describePlugin runs `describe` and parses its output. The plugin's stderr
+// is captured, not shown, and returned.
+func describePlugin(path string) (Manifest, string, error) {
 	cmd := exec.Command(path, "describe")
 	var stdout bytes.Buffer
+	stderr := &limitedBuffer{limit: describeStderrLimit}
 	cmd.Stdout = &stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stderr = stderr
 	if err := cmd.Run(); err != nil {
-		return Manifest{}, err
+		return Manifest{}, stderr.String(), err
 	}
-	return ParseManifest(stdout.Bytes())
+	manifest, err := parseDescribeOutput(stdout.Bytes())
+	return manifest, stderr.String(), err
 }
 
 func BuildTasks(plugins []PluginRecord) []TaskRecord {
@@ -85,12 +167,18 @@ func BuildTasks(plugins []PluginRecord) []TaskRecord {
 	for _, plugin := range plugins {
 		for _, task := range plugin.Manifest.Tasks {
 			tasks = append(tasks, TaskRecord{
-				PluginID:    plugin.Manifest.Plugin.ID,
-				PluginTitle: plugin.Manifest.Plugin.Title,
-				Task:        task,
-				Scope:       plugin.Scope,
-				PluginPath:  plugin.Path,
-				DirectExec:  plugin.DirectExec,
+				PluginID:         plugin.Manifest.Plugin.ID,
+				PluginTitle:      plugin.Manifest.Plugin.Title,
+				Task:             task,
+				Scope:            plugin.Scope,
+				PluginPath:       plugin.Path,
+				DirectExec:       plugin.DirectExec,
+				PluginEnv:        plugin.Manifest.Plugin.Env,
+				PluginInheritEnv: plugin.Manifest.Plugin.InheritEnv,
+				PluginWorkdir:    plugin.Manifest.Plugin.Workdir,
+				SpecPath:         plugin.SpecPath,
+				DescribeStderr:   plugin.DescribeStderr,
+				LevelRoot:        plugin.Root,
 			})
 		}
 	}
@@ -98,42 +186,96 @@ func BuildTasks(plugins []PluginRecord) []TaskRecord {
 }
 
 func RunPluginTask(task TaskRecord, repoRoot, cwd string, args map[string]any) error {
-	input := map[string]any{
-		"args": args,
-		"ctx": map[string]any{
-			"repoRoot":       repoRoot,
-			"cwd":            cwd,
-			"selectedTaskId": TaskID(task.PluginID, task.Task.Name),
-		},
+	return RunPluginTaskWithEnv(task, repoRoot, cwd, args, nil)
+}
+
+// RunPluginTaskWithEnv runs a task with overrides applied last to its
+// environment (see TaskEnv).
+func RunPluginTaskWithEnv(task TaskRecord, repoRoot, cwd string, args map[string]any, overrides map[string]string) error {
+	payload, err := taskPayload(task, repoRoot, cwd, args)
+	if err != nil {
+		return err
 	}
-	payload, err := json.Marshal(input)
+	workdir, err := TaskWorkdir(task, repoRoot, cwd)
+	if err != nil {
+		return err
+	}
+	env, err := TaskEnv(task, repoRoot, cwd, overrides)
 	if err != nil {
-		return fmt.Errorf("encode input JSON: %w", err)
+		return err
 	}
 	var cmd *exec.Cmd
-	if task.DirectExec {
-		cmd = exec.Command(task.PluginPath)
-		if repoRoot != "" {
-			cmd.Dir = repoRoot
+	switch {
+	case task.DirectExec && task.Task.Script != "":
+		argv, cleanup, err := scriptCommand(task)
+		if err != nil {
+			return err
 		}
-	} else {
+		defer cleanup()
+		cmd = exec.Command(argv[0], argv[1:]...)
+		env = append(env, scriptArgEnv(task.Task.Inputs, args)...)
+	case task.DirectExec:
+		argv, err := directCommand(task, repoRoot, cwd, workdir, args)
+		if err != nil {
+			return err
+		}
+		cmd = exec.Command(argv[0], argv[1:]...)
+		env = append(env, secretArgEnv(task.Task.Inputs, args)...)
+	default:
 		cmd = exec.Command(task.PluginPath, "run", task.Task.Name)
 	}
+	cmd.Dir = workdir
+	redactor, err := TaskRedactor(task, repoRoot, args, env)
+	if err != nil {
+		return err
+	}
+	stdout, stderr, flush := outputWriters(redactor)
+	defer flush()
 	cmd.Stdin = bytes.NewReader(payload)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	cmd.Env = append(os.Environ(), pluginEnv(task, repoRoot, cwd)...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.Env = env
 	if err := cmd.Run(); err != nil {
 		return err
 	}
 	return nil
 }
 
-func pluginEnv(task TaskRecord, repoRoot, cwd string) []string {
+func taskPayload(task TaskRecord, repoRoot, cwd string, args map[string]any) ([]byte, error) {
+	workdir, err := TaskWorkdir(task, repoRoot, cwd)
+	if err != nil {
+		return nil, err
+	}
+	if workdir == "" {
+		workdir = cwd
+	}
+	input := map[string]any{
+		"args": args,
+		"ctx": map[string]any{
+			"repoRoot":       repoRoot,
+			"outerRepoRoot":  OuterRepoRoot(repoRoot),
+			"cwd":            cwd,
+			"workdir":        workdir,
+			"selectedTaskId": TaskID(task.PluginID, task.Task.Name),
+		},
+	}
+	payload, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("encode input JSON: %w", err)
+	}
+	return payload, nil
+}
+
+func pluginEnv(task TaskRecord, repoRoot, cwd, workdir string) []string {
 	taskID := TaskID(task.PluginID, task.Task.Name)
+	if workdir == "" {
+		workdir = cwd
+	}
 	return []string{
 		"AUTOMATE_ME_REPO_ROOT=" + repoRoot,
+		"AUTOMATE_ME_OUTER_REPO_ROOT=" + OuterRepoRoot(repoRoot),
 		"AUTOMATE_ME_CWD=" + cwd,
+		"AUTOMATE_ME_WORKDIR=" + workdir,
 		"AUTOMATE_ME_TASK_ID=" + taskID,
 		"AUTOMATE_ME_PLUGIN_ID=" + task.PluginID,
 		"AUTOMATE_ME_TASK_NAME=" + task.Task.Name,
@@ -144,3 +286,27 @@ func pluginEnv(task TaskRecord, repoRoot, cwd string) []string {
 func TaskID(pluginID, taskName string) string {
 	return fmt.Sprintf("%s:%s", pluginID, taskName)
 }
+
+// limitedBuffer keeps the first limit bytes written to it and notes that
+// the rest was dropped.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); len(p) > room {
+		b.buf.Write(p[:max(room, 0)])
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	if b.truncated {
+		return b.buf.String() + "\n[truncated]\n"
+	}
+	return b.buf.String()
+}
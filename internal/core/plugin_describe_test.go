@@ -0,0 +1,59 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestLoadPluginsCapturesDescribeStderr(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping shell script test on windows")
+	}
+	base := t.TempDir()
+	globalConfig := filepath.Join(base, "config")
+	globalBin := filepath.Join(globalConfig, globalConfigDirName, binDirName)
+	if err := os.MkdirAll(globalBin, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	noisy := "#!/bin/sh\necho 'deprecated flag' >&2\necho '{\"schemaVersion\":2,\"plugin\":{\"id\":\"noisy\",\"title\":\"Noisy\"},\"tasks\":[{\"name\":\"t\",\"title\":\"t\"}]}'\n"
+	if err := os.WriteFile(filepath.Join(globalBin, "noisy"), []byte(noisy), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	broken := "#!/bin/sh\necho 'missing dependency' >&2\nexit 1\n"
+	if err := os.WriteFile(filepath.Join(globalBin, "broken"), []byte(broken), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("XDG_CONFIG_HOME", globalConfig)
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+
+	plugins, diagnostics, err := LoadPlugins("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plugins) != 1 || plugins[0].DescribeStderr != "deprecated flag\n" {
+		t.Fatalf("expected stderr on the plugin record, got %#v", plugins)
+	}
+	tasks := BuildTasks(plugins)
+	if len(tasks) != 1 || tasks[0].DescribeStderr != "deprecated flag\n" {
+		t.Fatalf("expected stderr on the task record, got %#v", tasks)
+	}
+	if len(diagnostics) != 1 || diagnostics[0].Detail != "missing dependency\n" || diagnostics[0].Severity != SeverityError {
+		t.Fatalf("expected failed describe diagnostic with its stderr, got %#v", diagnostics)
+	}
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	buf := &limitedBuffer{limit: 8}
+	if n, err := buf.Write([]byte("12345")); n != 5 || err != nil {
+		t.Fatalf("unexpected write: %d %v", n, err)
+	}
+	if n, err := buf.Write([]byte("67890")); n != 5 || err != nil {
+		t.Fatalf("expected dropped bytes to count as written: %d %v", n, err)
+	}
+	buf.Write([]byte("more"))
+	if got := buf.String(); got != "12345678\n[truncated]\n" {
+		t.Fatalf("unexpected buffer: %q", got)
+	}
+}
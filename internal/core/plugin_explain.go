@@ -0,0 +1,181 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kinds of plugin candidates.
+const (
+	KindProvider = "provider"
+	KindProtocol = "protocol"
+	KindSpec     = "spec"
+)
+
+// Statuses of plugin candidates.
+const (
+	CandidateSelected  = "selected"
+	CandidateShadowed  = "shadowed"
+	CandidateUntrusted = "untrusted"
+	CandidateFailed    = "failed"
+)
+
+// PluginCandidate is one provider, protocol plugin or spec considered while
+// loading plugins. Reason says why it was selected, shadowed or skipped;
+// Stderr holds what its describe printed there.
+type PluginCandidate struct {
+	ID     string      `json:"id,omitempty"`
+	Kind   string      `json:"kind"`
+	Scope  PluginScope `json:"scope"`
+	Path   string      `json:"path"`
+	Status string      `json:"status"`
+	Reason string      `json:"reason,omitempty"`
+	Stderr string      `json:"stderr,omitempty"`
+}
+
+// PluginResolution lists the candidates for one plugin id in the order they
+// were considered, and the task names the selected one declares twice.
+type PluginResolution struct {
+	ID             string            `json:"id"`
+	Candidates     []PluginCandidate `json:"candidates"`
+	DuplicateTasks []string          `json:"duplicateTasks,omitempty"`
+}
+
+// PluginExplanation is the outcome of loading plugins: the resolution of
+// every plugin id, sorted, the candidates skipped before their id was known
+// or because they may not run, and the diagnostics found on the way.
+type PluginExplanation struct {
+	Plugins     []PluginResolution `json:"plugins"`
+	Skipped     []PluginCandidate  `json:"skipped,omitempty"`
+	Diagnostics Diagnostics        `json:"diagnostics,omitempty"`
+}
+
+// pluginResolver applies the precedence between candidates with the same
+// id: nearer local levels over outer ones, local over global, anything over
+// built-in providers, specs over protocol plugins of the same scope and,
+// otherwise, the last one loaded.
+type pluginResolver struct {
+	byID        map[string]PluginRecord
+	resolutions map[string]*PluginResolution
+	skipped     []PluginCandidate
+	diagnostics Diagnostics
+}
+
+func newPluginResolver() *pluginResolver {
+	return &pluginResolver{
+		byID:        make(map[string]PluginRecord),
+		resolutions: make(map[string]*PluginResolution),
+	}
+}
+
+func (r *pluginResolver) add(record PluginRecord, kind string) {
+	id := record.Manifest.Plugin.ID
+	candidate := PluginCandidate{ID: id, Kind: kind, Scope: record.Scope, Path: record.Path, Status: CandidateSelected, Stderr: record.DescribeStderr}
+	if record.SpecPath != "" {
+		candidate.Path = record.SpecPath
+	}
+	resolution, ok := r.resolutions[id]
+	if !ok {
+		resolution = &PluginResolution{ID: id}
+		r.resolutions[id] = resolution
+	}
+	if existing, ok := r.byID[id]; ok {
+		current := selectedIndex(resolution.Candidates)
+		winner := resolution.Candidates[current]
+		if scopeRank(record.Scope) < scopeRank(existing.Scope) {
+			candidate.Status = CandidateShadowed
+			candidate.Reason = fmt.Sprintf("shadowed by %s: %s", winner.Path, precedenceRule(winner, candidate))
+			resolution.Candidates = append(resolution.Candidates, candidate)
+			return
+		}
+		if existing.Scope == ScopeGlobal && record.Scope.IsLocal() {
+			local := "local"
+			if kind == KindSpec {
+				local = "local spec"
+			}
+			r.diagnostics.Warn(candidate.Path, fmt.Sprintf("plugin id %s overridden by %s %s (was %s)", id, local, candidate.Path, existing.Path), nil)
+		}
+		resolution.Candidates[current].Status = CandidateShadowed
+		resolution.Candidates[current].Reason = fmt.Sprintf("shadowed by %s: %s", candidate.Path, precedenceRule(candidate, winner))
+	}
+	r.byID[id] = record
+	resolution.Candidates = append(resolution.Candidates, candidate)
+}
+
+// skip records a candidate that was not loaded at all.
+func (r *pluginResolver) skip(candidate PluginCandidate) {
+	r.skipped = append(r.skipped, candidate)
+}
+
+func (r *pluginResolver) plugins() []PluginRecord {
+	var plugins []PluginRecord
+	for _, plugin := range r.byID {
+		plugins = append(plugins, plugin)
+	}
+	return plugins
+}
+
+func (r *pluginResolver) explanation() PluginExplanation {
+	explanation := PluginExplanation{Skipped: r.skipped, Diagnostics: r.diagnostics}
+	for _, id := range sortedKeys(r.resolutions) {
+		resolution := *r.resolutions[id]
+		resolution.Candidates = append([]PluginCandidate(nil), resolution.Candidates...)
+		current := selectedIndex(resolution.Candidates)
+		var rules []string
+		for _, candidate := range resolution.Candidates {
+			if candidate.Status == CandidateShadowed {
+				rule := precedenceRule(resolution.Candidates[current], candidate)
+				if !contains(rules, rule) {
+					rules = append(rules, rule)
+				}
+			}
+		}
+		if len(rules) == 0 {
+			resolution.Candidates[current].Reason = "only candidate"
+		} else {
+			resolution.Candidates[current].Reason = strings.Join(rules, "; ")
+		}
+		resolution.DuplicateTasks = duplicateTaskNames(r.byID[id].Manifest)
+		explanation.Plugins = append(explanation.Plugins, resolution)
+	}
+	return explanation
+}
+
+// precedenceRule says why winner takes precedence over loser.
+func precedenceRule(winner, loser PluginCandidate) string {
+	switch {
+	case winner.Scope.IsLocal() && loser.Scope == ScopeGlobal:
+		return "local plugins take precedence over global ones"
+	case winner.Scope.IsLocal() && loser.Scope.IsLocal() && winner.Scope != loser.Scope:
+		return "nearer .automate-me dirs take precedence over outer ones"
+	case loser.Scope == ScopeBuiltin:
+		return "plugins and specs replace built-in providers"
+	case winner.Kind == KindSpec && loser.Kind == KindProtocol:
+		return "specs take precedence over protocol plugins in the same scope"
+	default:
+		return fmt.Sprintf("the last %s %s loaded wins", winner.Scope, winner.Kind)
+	}
+}
+
+func selectedIndex(candidates []PluginCandidate) int {
+	for i, candidate := range candidates {
+		if candidate.Status == CandidateSelected {
+			return i
+		}
+	}
+	return -1
+}
+
+func duplicateTaskNames(manifest Manifest) []string {
+	seen := make(map[string]int)
+	for _, task := range manifest.Tasks {
+		seen[task.Name]++
+	}
+	var duplicates []string
+	for _, name := range sortedKeys(seen) {
+		if seen[name] > 1 {
+			duplicates = append(duplicates, name)
+		}
+	}
+	return duplicates
+}
@@ -0,0 +1,98 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestExplainPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping shell script test on windows")
+	}
+	base := t.TempDir()
+	repo := filepath.Join(base, "repo")
+	localSpecDir, err := newPathConfig(repo).localSpecs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	globalConfig := filepath.Join(base, "config")
+	globalBin := filepath.Join(globalConfig, globalConfigDirName, binDirName)
+	for _, dir := range []string{localSpecDir, globalBin} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	plugin := "#!/bin/sh\necho '{\"schemaVersion\":2,\"plugin\":{\"id\":\"p\",\"title\":\"global\"},\"tasks\":[]}'\n"
+	if err := os.WriteFile(filepath.Join(globalBin, "p"), []byte(plugin), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	broken := "#!/bin/sh\necho 'cannot describe' >&2\nexit 3\n"
+	if err := os.WriteFile(filepath.Join(globalBin, "broken"), []byte(broken), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	spec := `{"schemaVersion":2,"plugin":{"id":"p","title":"local","exec":"/bin/echo"},"tasks":[{"name":"t","title":"t"},{"name":"t","title":"again"}]}`
+	specPath := filepath.Join(localSpecDir, "p.json")
+	if err := os.WriteFile(specPath, []byte(spec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("XDG_CONFIG_HOME", globalConfig)
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+
+	if _, err := TrustRepo(repo); err != nil {
+		t.Fatal(err)
+	}
+
+	explanation, err := ExplainPlugins(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(explanation.Plugins) != 1 {
+		t.Fatalf("expected 1 plugin id, got %#v", explanation.Plugins)
+	}
+	resolution := explanation.Plugins[0]
+	if len(resolution.Candidates) != 2 {
+		t.Fatalf("expected 2 candidates, got %#v", resolution.Candidates)
+	}
+	shadowed, selected := resolution.Candidates[0], resolution.Candidates[1]
+	if shadowed.Kind != KindProtocol || shadowed.Status != CandidateShadowed || !strings.Contains(shadowed.Reason, specPath) {
+		t.Fatalf("unexpected shadowed candidate: %#v", shadowed)
+	}
+	if selected.Kind != KindSpec || selected.Status != CandidateSelected || selected.Path != specPath {
+		t.Fatalf("unexpected selected candidate: %#v", selected)
+	}
+	if selected.Reason != "local plugins take precedence over global ones" {
+		t.Fatalf("unexpected reason: %s", selected.Reason)
+	}
+	if strings.Join(resolution.DuplicateTasks, ",") != "t" {
+		t.Fatalf("expected duplicate task t, got %v", resolution.DuplicateTasks)
+	}
+	if len(explanation.Skipped) != 1 {
+		t.Fatalf("expected 1 skipped candidate, got %#v", explanation.Skipped)
+	}
+	failed := explanation.Skipped[0]
+	if failed.Status != CandidateFailed || failed.Stderr != "cannot describe\n" {
+		t.Fatalf("unexpected failed candidate: %#v", failed)
+	}
+}
+
+func TestPrecedenceRule(t *testing.T) {
+	cases := []struct {
+		winner, loser PluginCandidate
+		want          string
+	}{
+		{PluginCandidate{Kind: KindProtocol, Scope: ScopeLocal}, PluginCandidate{Kind: KindSpec, Scope: ScopeGlobal}, "local plugins take precedence over global ones"},
+		{PluginCandidate{Kind: KindProtocol, Scope: ScopeGlobal}, PluginCandidate{Kind: KindProvider, Scope: ScopeBuiltin}, "plugins and specs replace built-in providers"},
+		{PluginCandidate{Kind: KindSpec, Scope: ScopeGlobal}, PluginCandidate{Kind: KindProtocol, Scope: ScopeGlobal}, "specs take precedence over protocol plugins in the same scope"},
+		{PluginCandidate{Kind: KindSpec, Scope: ScopeLocal}, PluginCandidate{Kind: KindSpec, Scope: ScopeLocal}, "the last local spec loaded wins"},
+		{PluginCandidate{Kind: KindProtocol, Scope: ScopeLocal}, PluginCandidate{Kind: KindSpec, Scope: LevelScope(1)}, "nearer .automate-me dirs take precedence over outer ones"},
+	}
+	for _, tc := range cases {
+		if got := precedenceRule(tc.winner, tc.loser); got != tc.want {
+			t.Fatalf("precedenceRule(%v, %v) = %q, want %q", tc.winner, tc.loser, got, tc.want)
+		}
+	}
+}
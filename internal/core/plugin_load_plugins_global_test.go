@@ -22,7 +22,7 @@ func TestLoadPluginsNoRepoRootLoadsGlobalSpecs(t *testing.T) {
 	os.Setenv("XDG_CONFIG_HOME", configDir)
 	defer os.Unsetenv("XDG_CONFIG_HOME")
 
-	plugins, err := LoadPlugins("")
+	plugins, _, err := LoadPlugins("")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -35,7 +35,11 @@ func TestLoadPluginsSpecPrecedence(t *testing.T) {
 	os.Setenv("XDG_CONFIG_HOME", globalConfig)
 	defer os.Unsetenv("XDG_CONFIG_HOME")
 
-	plugins, err := LoadPlugins(repo)
+	if _, err := TrustRepo(repo); err != nil {
+		t.Fatal(err)
+	}
+
+	plugins, _, err := LoadPlugins(repo)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -46,3 +50,81 @@ func TestLoadPluginsSpecPrecedence(t *testing.T) {
 		t.Fatalf("expected local spec to win, got %s", plugins[0].Manifest.Plugin.Title)
 	}
 }
+
+func TestLoadPluginsNestedLevels(t *testing.T) {
+	base := t.TempDir()
+	repo := filepath.Join(base, "repo")
+	pkg := filepath.Join(repo, "packages", "api")
+	rootSpecDir, err := newPathConfig(repo).localSpecs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkgSpecDir, err := newPathConfig(pkg).localSpecs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{filepath.Join(repo, gitDirName), rootSpecDir, pkgSpecDir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	specs := map[string]string{
+		filepath.Join(rootSpecDir, "shared.json"): `{"schemaVersion":2,"plugin":{"id":"shared","title":"root","exec":"/bin/echo"},"tasks":[{"name":"t","title":"t"}]}`,
+		filepath.Join(rootSpecDir, "lint.json"):   `{"schemaVersion":2,"plugin":{"id":"lint","title":"root","exec":"bin/lint"},"tasks":[{"name":"t","title":"t"}]}`,
+		filepath.Join(pkgSpecDir, "shared.json"):  `{"schemaVersion":2,"plugin":{"id":"shared","title":"package","exec":"/bin/echo"},"tasks":[{"name":"t","title":"t"}]}`,
+	}
+	for path, spec := range specs {
+		if err := os.WriteFile(path, []byte(spec), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+
+	for _, root := range []string{pkg, repo} {
+		if _, err := TrustRepo(root); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	plugins, _, err := LoadPlugins(pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byID := make(map[string]PluginRecord)
+	for _, plugin := range plugins {
+		byID[plugin.Manifest.Plugin.ID] = plugin
+	}
+	if len(byID) != 2 {
+		t.Fatalf("expected plugins of both levels, got %v", byID)
+	}
+	if shared := byID["shared"]; shared.Manifest.Plugin.Title != "package" || shared.Scope != ScopeLocal || shared.Root != pkg {
+		t.Fatalf("expected nearest spec to win, got %#v", shared)
+	}
+	if lint := byID["lint"]; lint.Scope != LevelScope(1) || lint.Root != repo {
+		t.Fatalf("expected outer spec in scope local:1, got %#v", lint)
+	}
+
+	explanation, err := ExplainPlugins(pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, resolution := range explanation.Plugins {
+		if resolution.ID != "shared" {
+			continue
+		}
+		if len(resolution.Candidates) != 2 || resolution.Candidates[1].Status != CandidateShadowed || resolution.Candidates[0].Reason != "nearer .automate-me dirs take precedence over outer ones" {
+			t.Fatalf("unexpected resolution: %#v", resolution)
+		}
+	}
+
+	task := BuildTasks([]PluginRecord{byID["lint"]})[0]
+	argv, err := directCommand(task, pkg, pkg, pkg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if argv[0] != filepath.Join(repo, "bin", "lint") {
+		t.Fatalf("expected exec relative to its level, got %s", argv[0])
+	}
+}
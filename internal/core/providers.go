@@ -0,0 +1,321 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// taskProvider turns a well-known task file in the repo root into a virtual
+// direct-exec plugin, parsed in Go without running anything.
+type taskProvider struct {
+	ID    string
+	Title string
+	Files []string
+	Parse func(path string) ([]TaskSpec, error)
+}
+
+var builtinProviders = []taskProvider{
+	{ID: "make", Title: "Make", Files: []string{"GNUmakefile", "makefile", "Makefile"}, Parse: parseMakefile},
+	{ID: "package", Title: "Package Scripts", Files: []string{"package.json"}, Parse: parsePackageJSON},
+	{ID: "just", Title: "Just", Files: []string{"justfile", "Justfile", ".justfile"}, Parse: parseJustfile},
+	{ID: "taskfile", Title: "Taskfile", Files: []string{"Taskfile.yml", "taskfile.yml", "Taskfile.yaml", "taskfile.yaml"}, Parse: parseTaskfile},
+}
+
+// loadProviders returns a plugin per enabled provider whose file exists in
+// repoRoot and declares at least one task. Files that cannot be parsed are
+// reported as diagnostics.
+func loadProviders(repoRoot string) ([]PluginRecord, Diagnostics, error) {
+	if repoRoot == "" {
+		return nil, nil, nil
+	}
+	config, err := LoadConfig(repoRoot)
+	if err != nil {
+		return nil, nil, err
+	}
+	var records []PluginRecord
+	var diagnostics Diagnostics
+	for _, provider := range builtinProviders {
+		if enabled, ok := config.Providers[provider.ID]; ok && !enabled {
+			continue
+		}
+		path := ""
+		for _, name := range provider.Files {
+			candidate := filepath.Join(repoRoot, name)
+			if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+				path = candidate
+				break
+			}
+		}
+		if path == "" {
+			continue
+		}
+		tasks, err := provider.Parse(path)
+		if err != nil {
+			diagnostics.Error(path, provider.ID+" provider", err)
+			continue
+		}
+		if len(tasks) == 0 {
+			continue
+		}
+		for i := range tasks {
+			if tasks[i].Title == "" {
+				tasks[i].Title = tasks[i].Name
+			}
+			tasks[i].Group = provider.Title
+		}
+		records = append(records, PluginRecord{
+			Path:  path,
+			Scope: ScopeBuiltin,
+			Manifest: Manifest{
+				SchemaVersion: CurrentSchemaVersion,
+				Plugin:        PluginInfo{ID: provider.ID, Title: provider.Title},
+				Tasks:         tasks,
+			},
+			DirectExec: true,
+		})
+	}
+	return records, diagnostics, nil
+}
+
+// parseMakefile lists explicit targets, skipping special (.PHONY), pattern
+// and file-path targets. A "## text" comment above a target or after its
+// prerequisites becomes the description.
+func parseMakefile(path string) ([]TaskSpec, error) {
+	lines, err := readLines(path)
+	if err != nil {
+		return nil, err
+	}
+	var tasks []TaskSpec
+	seen := make(map[string]bool)
+	comment := ""
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "\t"), trimmed == "":
+			comment = ""
+			continue
+		case strings.HasPrefix(trimmed, "##"):
+			comment = strings.TrimSpace(strings.TrimLeft(trimmed, "#"))
+			continue
+		case strings.HasPrefix(trimmed, "#"):
+			continue
+		}
+		head, rest, ok := strings.Cut(line, ":")
+		if !ok || strings.HasPrefix(rest, "=") || strings.HasPrefix(rest, ":=") || strings.ContainsAny(head, "=$%") {
+			comment = ""
+			continue
+		}
+		description := comment
+		if _, after, ok := strings.Cut(rest, "##"); ok {
+			description = strings.TrimSpace(after)
+		}
+		comment = ""
+		for _, name := range strings.Fields(head) {
+			if strings.HasPrefix(name, ".") || strings.ContainsRune(name, '/') || seen[name] {
+				continue
+			}
+			seen[name] = true
+			tasks = append(tasks, TaskSpec{Name: name, Description: description, Command: []string{"make", name}})
+		}
+	}
+	return tasks, nil
+}
+
+// parsePackageJSON lists scripts, run with the package manager implied by
+// the lockfile next to package.json.
+func parsePackageJSON(path string) ([]TaskSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	runner := "npm"
+	dir := filepath.Dir(path)
+	for _, lock := range []struct{ file, runner string }{
+		{"pnpm-lock.yaml", "pnpm"},
+		{"yarn.lock", "yarn"},
+		{"bun.lockb", "bun"},
+		{"bun.lock", "bun"},
+	} {
+		if exists(filepath.Join(dir, lock.file)) {
+			runner = lock.runner
+			break
+		}
+	}
+	names := make([]string, 0, len(pkg.Scripts))
+	for name := range pkg.Scripts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var tasks []TaskSpec
+	for _, name := range names {
+		tasks = append(tasks, TaskSpec{Name: name, Description: pkg.Scripts[name], Command: []string{runner, "run", name}})
+	}
+	return tasks, nil
+}
+
+// parseJustfile lists public recipes. Recipe parameters become inputs
+// (variadic ones lists) and a "# text" comment above a recipe its
+// description.
+func parseJustfile(path string) ([]TaskSpec, error) {
+	lines, err := readLines(path)
+	if err != nil {
+		return nil, err
+	}
+	var tasks []TaskSpec
+	comment := ""
+	private := false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "", strings.HasPrefix(line, " "), strings.HasPrefix(line, "\t"):
+			comment, private = "", false
+			continue
+		case strings.HasPrefix(trimmed, "#"):
+			comment = strings.TrimSpace(strings.TrimPrefix(trimmed, "#"))
+			continue
+		case strings.HasPrefix(trimmed, "["):
+			if strings.Contains(trimmed, "private") {
+				private = true
+			}
+			continue
+		}
+		head, rest, ok := cutOutsideQuotes(line, ':')
+		fields := splitQuoted(strings.TrimPrefix(head, "@"))
+		if !ok || strings.HasPrefix(rest, "=") || len(fields) == 0 {
+			comment, private = "", false
+			continue
+		}
+		switch fields[0] {
+		case "set", "alias", "export", "import", "mod":
+			comment, private = "", false
+			continue
+		}
+		name := fields[0]
+		if private || strings.HasPrefix(name, "_") {
+			comment, private = "", false
+			continue
+		}
+		task := TaskSpec{Name: name, Description: comment, Command: []string{"just", name}}
+		for _, param := range fields[1:] {
+			input := InputSpec{Type: "string"}
+			switch {
+			case strings.HasPrefix(param, "+"):
+				input.Type, input.Required = "list", true
+			case strings.HasPrefix(param, "*"):
+				input.Type = "list"
+			}
+			param = strings.TrimLeft(param, "+*$")
+			paramName, value, hasDefault := strings.Cut(param, "=")
+			input.Name = paramName
+			if hasDefault {
+				input.Default = strings.Trim(value, `"'`)
+			} else if input.Type == "string" {
+				input.Required = true
+			}
+			task.Inputs = append(task.Inputs, input)
+			task.Command = append(task.Command, "{{.args."+paramName+"}}")
+		}
+		tasks = append(tasks, task)
+		comment, private = "", false
+	}
+	return tasks, nil
+}
+
+// parseTaskfile reads the task names, desc and internal flags under the
+// top-level tasks key of a Taskfile, in file order.
+func parseTaskfile(path string) ([]TaskSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Tasks yaml.Node `yaml:"tasks"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if file.Tasks.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	var tasks []TaskSpec
+	for i := 0; i+1 < len(file.Tasks.Content); i += 2 {
+		name := file.Tasks.Content[i].Value
+		// Tasks written as a command string or list have no desc.
+		var props struct {
+			Desc     string `yaml:"desc"`
+			Internal bool   `yaml:"internal"`
+		}
+		if value := file.Tasks.Content[i+1]; value.Kind == yaml.MappingNode {
+			if err := value.Decode(&props); err != nil {
+				return nil, fmt.Errorf("parse %s: task %s: %w", path, name, err)
+			}
+		}
+		if props.Internal {
+			continue
+		}
+		tasks = append(tasks, TaskSpec{Name: name, Description: strings.TrimSpace(props.Desc), Command: []string{"task", name}})
+	}
+	return tasks, nil
+}
+
+func readLines(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimRight(scanner.Text(), "\r"))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	return lines, nil
+}
+
+// cutOutsideQuotes is strings.Cut ignoring separators inside quotes.
+func cutOutsideQuotes(s string, sep byte) (string, string, bool) {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == sep:
+			return s[:i], s[i+1:], true
+		}
+	}
+	return s, "", false
+}
+
+// splitQuoted splits on spaces outside quotes.
+func splitQuoted(s string) []string {
+	var fields []string
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			return fields
+		}
+		field, rest, _ := cutOutsideQuotes(s, ' ')
+		fields = append(fields, field)
+		s = rest
+	}
+}
@@ -0,0 +1,186 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeProviderFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func taskNames(tasks []TaskSpec) string {
+	var names []string
+	for _, task := range tasks {
+		names = append(names, task.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestParseMakefile(t *testing.T) {
+	path := writeProviderFile(t, t.TempDir(), "Makefile", `VERSION := 1.0
+.PHONY: build test
+
+## Build the binary
+build: deps
+	go build ./...
+
+test lint: ## Run checks
+	go test ./...
+
+%.o: %.c
+	cc -c $<
+
+bin/tool:
+	touch $@
+`)
+	tasks, err := parseMakefile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := taskNames(tasks); got != "build,test,lint" {
+		t.Fatalf("unexpected targets %q", got)
+	}
+	if tasks[0].Description != "Build the binary" || tasks[1].Description != "Run checks" {
+		t.Fatalf("unexpected descriptions %+v", tasks)
+	}
+	if strings.Join(tasks[0].Command, " ") != "make build" {
+		t.Fatalf("unexpected command %v", tasks[0].Command)
+	}
+}
+
+func TestParsePackageJSONUsesLockfileRunner(t *testing.T) {
+	dir := t.TempDir()
+	path := writeProviderFile(t, dir, "package.json", `{"scripts": {"test": "jest", "build": "tsc"}}`)
+	writeProviderFile(t, dir, "pnpm-lock.yaml", "")
+	tasks, err := parsePackageJSON(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := taskNames(tasks); got != "build,test" {
+		t.Fatalf("unexpected scripts %q", got)
+	}
+	if strings.Join(tasks[1].Command, " ") != "pnpm run test" || tasks[1].Description != "jest" {
+		t.Fatalf("unexpected task %+v", tasks[1])
+	}
+}
+
+func TestParseJustfile(t *testing.T) {
+	path := writeProviderFile(t, t.TempDir(), "justfile", `set shell := ["bash", "-c"]
+alias b := build
+
+# Build for a target
+build target="linux":
+    go build
+
+deploy env +hosts:
+    echo {{env}}
+
+[private]
+helper:
+    true
+
+_hidden:
+    true
+`)
+	tasks, err := parseJustfile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := taskNames(tasks); got != "build,deploy" {
+		t.Fatalf("unexpected recipes %q", got)
+	}
+	build := tasks[0]
+	if build.Description != "Build for a target" || len(build.Inputs) != 1 || build.Inputs[0].Default != "linux" || build.Inputs[0].Required {
+		t.Fatalf("unexpected build recipe %+v", build)
+	}
+	deploy := tasks[1]
+	if len(deploy.Inputs) != 2 || !deploy.Inputs[0].Required || deploy.Inputs[1].Type != "list" || !deploy.Inputs[1].Required {
+		t.Fatalf("unexpected deploy inputs %+v", deploy.Inputs)
+	}
+	if strings.Join(deploy.Command, " ") != "just deploy {{.args.env}} {{.args.hosts}}" {
+		t.Fatalf("unexpected command %v", deploy.Command)
+	}
+}
+
+func TestParseTaskfile(t *testing.T) {
+	path := writeProviderFile(t, t.TempDir(), "Taskfile.yml", `version: '3'
+
+vars:
+  NAME: app
+
+tasks:
+  build:
+    desc: Build the app
+    cmds:
+      - go build
+  setup:
+    internal: true
+    cmds:
+      - desc: not a task desc
+  "lint":
+    cmds: [golangci-lint run]
+  release:
+    desc: |
+      Tag and publish: the
+      whole thing
+    cmds: [{task: build}]
+  "docs:serve": {desc: "Serve docs", cmds: [mkdocs serve]}
+  fmt: gofmt -w .
+  test:
+    desc: >-
+      Run the
+      tests
+`)
+	tasks, err := parseTaskfile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := taskNames(tasks); got != "build,lint,release,docs:serve,fmt,test" {
+		t.Fatalf("unexpected tasks %q", got)
+	}
+	if tasks[0].Description != "Build the app" || tasks[1].Description != "" || tasks[2].Description != "Tag and publish: the\nwhole thing" || tasks[3].Description != "Serve docs" || tasks[5].Description != "Run the tests" {
+		t.Fatalf("unexpected descriptions %+v", tasks)
+	}
+}
+
+func TestLoadPluginsProviders(t *testing.T) {
+	base := t.TempDir()
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	repo := filepath.Join(base, "repo")
+	if err := os.MkdirAll(filepath.Join(repo, ".automate-me"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeProviderFile(t, repo, "Makefile", "build:\n\tgo build\n")
+	writeProviderFile(t, repo, "package.json", `{"scripts": {"test": "jest"}}`)
+
+	plugins, _, err := LoadPlugins(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plugins) != 2 {
+		t.Fatalf("expected make and package providers, got %d", len(plugins))
+	}
+	for _, plugin := range plugins {
+		if plugin.Scope != ScopeBuiltin || !plugin.DirectExec {
+			t.Fatalf("unexpected provider record %+v", plugin)
+		}
+	}
+
+	writeProviderFile(t, filepath.Join(repo, ".automate-me"), "config.json", `{"providers": {"make": false}}`)
+	plugins, _, err = LoadPlugins(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plugins) != 1 || plugins[0].Manifest.Plugin.ID != "package" {
+		t.Fatalf("expected only the package provider, got %+v", plugins)
+	}
+}
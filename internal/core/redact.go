@@ -0,0 +1,202 @@
+package core
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const redactedValue = "****"
+
+// minSecretLength avoids redacting tiny values that would garble output.
+const minSecretLength = 4
+
+// flushDelay bounds how long a partial line, such as a prompt or a progress
+// bar, is held back waiting for the rest of a value split across writes.
+const flushDelay = 100 * time.Millisecond
+
+// secretEnvMarkers flag env vars whose values are treated as secrets.
+var secretEnvMarkers = []string{"TOKEN", "SECRET", "PASSWORD", "PASSWD", "API_KEY"}
+
+// Redactor replaces known secret values and configured patterns in output.
+type Redactor struct {
+	secrets  []string
+	patterns []*regexp.Regexp
+}
+
+func NewRedactor(secrets []string, patterns []string) (*Redactor, error) {
+	r := &Redactor{}
+	seen := make(map[string]bool)
+	for _, secret := range secrets {
+		if len(secret) < minSecretLength || seen[secret] {
+			continue
+		}
+		seen[secret] = true
+		r.secrets = append(r.secrets, secret)
+	}
+	// Longest first so a secret containing another is fully replaced.
+	sort.Slice(r.secrets, func(i, j int) bool {
+		return len(r.secrets[i]) > len(r.secrets[j])
+	})
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// TaskRedactor collects the secrets of a run: secret input values, values
+// automate-me sets for env vars that look like credentials, and the
+// configured patterns. Variables inherited unchanged from the caller are
+// left alone, so a token in the user's shell alone does not take the task's
+// terminal away (see outputWriters).
+func TaskRedactor(task TaskRecord, repoRoot string, args map[string]any, env []string) (*Redactor, error) {
+	var secrets []string
+	for _, input := range task.Task.Inputs {
+		if !input.Secret {
+			continue
+		}
+		if value, ok := args[input.Name].(string); ok {
+			secrets = append(secrets, value)
+		}
+	}
+	for _, entry := range env {
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok || !isSecretEnvName(name) {
+			continue
+		}
+		if inherited, found := os.LookupEnv(name); found && inherited == value {
+			continue
+		}
+		secrets = append(secrets, value)
+	}
+	config, err := LoadConfig(repoRoot)
+	if err != nil {
+		return nil, err
+	}
+	return NewRedactor(secrets, config.Redact.Patterns)
+}
+
+func isSecretEnvName(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, marker := range secretEnvMarkers {
+		if strings.Contains(upper, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Redactor) Empty() bool {
+	return len(r.secrets) == 0 && len(r.patterns) == 0
+}
+
+func (r *Redactor) Redact(text string) string {
+	for _, secret := range r.secrets {
+		text = strings.ReplaceAll(text, secret, redactedValue)
+	}
+	for _, re := range r.patterns {
+		text = re.ReplaceAllString(text, redactedValue)
+	}
+	return text
+}
+
+// Writer wraps w so everything written through it is redacted. Output is
+// redacted line by line (\n or \r) so values split across writes are still
+// caught; a partial line is written after flushDelay, minus any tail that
+// could start a secret. Flush writes what is left.
+func (r *Redactor) Writer(w io.Writer) *RedactWriter {
+	return &RedactWriter{redactor: r, out: w}
+}
+
+type RedactWriter struct {
+	redactor *Redactor
+	out      io.Writer
+	mu       sync.Mutex
+	pending  []byte
+	timer    *time.Timer
+}
+
+func (w *RedactWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pending = append(w.pending, p...)
+	if idx := bytes.LastIndexAny(w.pending, "\n\r"); idx >= 0 {
+		lines := string(w.pending[:idx+1])
+		w.pending = append([]byte(nil), w.pending[idx+1:]...)
+		if _, err := io.WriteString(w.out, w.redactor.Redact(lines)); err != nil {
+			return 0, err
+		}
+	}
+	if len(w.pending) > 0 && w.timer == nil {
+		w.timer = time.AfterFunc(flushDelay, w.flushPartial)
+	}
+	return len(p), nil
+}
+
+func (w *RedactWriter) flushPartial() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.timer = nil
+	n := len(w.pending) - w.redactor.secretPrefixLen(w.pending)
+	if n <= 0 {
+		return
+	}
+	io.WriteString(w.out, w.redactor.Redact(string(w.pending[:n])))
+	w.pending = append([]byte(nil), w.pending[n:]...)
+}
+
+func (w *RedactWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	if len(w.pending) == 0 {
+		return nil
+	}
+	rest := string(w.pending)
+	w.pending = nil
+	_, err := io.WriteString(w.out, w.redactor.Redact(rest))
+	return err
+}
+
+// secretPrefixLen returns the length of the longest tail of text that is
+// the start of a secret.
+func (r *Redactor) secretPrefixLen(text []byte) int {
+	longest := 0
+	for _, secret := range r.secrets {
+		for k := min(len(secret)-1, len(text)); k > longest; k-- {
+			if bytes.HasSuffix(text, []byte(secret[:k])) {
+				longest = k
+				break
+			}
+		}
+	}
+	return longest
+}
+
+// outputWriters returns the stdout/stderr a task should write to, redacted
+// when there is anything to redact, and a flush func to call after it exits.
+// With nothing to redact the task writes to the terminal itself and keeps
+// its TTY, colors and interactive behavior.
+func outputWriters(redactor *Redactor) (io.Writer, io.Writer, func()) {
+	if redactor == nil || redactor.Empty() {
+		return os.Stdout, os.Stderr, func() {}
+	}
+	stdout := redactor.Writer(os.Stdout)
+	stderr := redactor.Writer(os.Stderr)
+	return stdout, stderr, func() {
+		stdout.Flush()
+		stderr.Flush()
+	}
+}
@@ -0,0 +1,141 @@
+package core
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRedactWriterAcrossWrites(t *testing.T) {
+	redactor, err := NewRedactor([]string{"hunter22", "ab"}, []string{`ghp_[A-Za-z0-9]+`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	w := redactor.Writer(&out)
+	for _, chunk := range []string{"pass=hun", "ter22 ab\n", "token ghp_abc123", " done"} {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := "pass=**** ab\ntoken **** done"
+	if out.String() != want {
+		t.Fatalf("expected %q, got %q", want, out.String())
+	}
+}
+
+func TestTaskRedactorCollectsSecrets(t *testing.T) {
+	base := t.TempDir()
+	configDir := filepath.Join(base, "config")
+	os.Setenv("XDG_CONFIG_HOME", configDir)
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+
+	repo := filepath.Join(base, "repo")
+	localRoot := filepath.Join(repo, localConfigDirName)
+	if err := os.MkdirAll(localRoot, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	config := `{"redact": {"patterns": ["id-[0-9]+"]}}`
+	if err := os.WriteFile(filepath.Join(localRoot, configFileName), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	task := TaskRecord{Task: TaskSpec{Name: "t", Inputs: []InputSpec{{Name: "pw", Type: "string", Secret: true}}}}
+	env := []string{"GITHUB_TOKEN=tok-value", "HOME=/home/me"}
+	redactor, err := TaskRedactor(task, repo, map[string]any{"pw": "p4ssword"}, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := redactor.Redact("p4ssword tok-value /home/me id-42")
+	if got != "**** **** /home/me ****" {
+		t.Fatalf("unexpected redaction: %q", got)
+	}
+}
+
+func TestLoadConfigRejectsInvalidPattern(t *testing.T) {
+	base := t.TempDir()
+	os.Setenv("XDG_CONFIG_HOME", base)
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	globalRoot := filepath.Join(base, globalConfigDirName)
+	if err := os.MkdirAll(globalRoot, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(globalRoot, configFileName), []byte(`{"redact":{"patterns":["("]}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(""); err == nil {
+		t.Fatal("expected invalid pattern error")
+	}
+}
+
+func TestRedactWriterFlushesPartialLines(t *testing.T) {
+	redactor, err := NewRedactor([]string{"hunter22"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out syncBuffer
+	w := redactor.Writer(&out)
+	if _, err := w.Write([]byte("progress 10%\rprogress 20%\rPassword: hun")); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "progress 10%\rprogress 20%\r" {
+		t.Fatalf("expected \\r to end a line, got %q", got)
+	}
+	time.Sleep(3 * flushDelay)
+	if got := out.String(); got != "progress 10%\rprogress 20%\rPassword: " {
+		t.Fatalf("expected partial line flushed without the possible secret start, got %q", got)
+	}
+	if _, err := w.Write([]byte("ter22\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); !strings.HasSuffix(got, "Password: ****\n") {
+		t.Fatalf("expected secret split across writes redacted, got %q", got)
+	}
+}
+
+func TestTaskRedactorSkipsInheritedEnv(t *testing.T) {
+	os.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	os.Setenv("AM_TEST_TOKEN", "inherited-token")
+	defer os.Unsetenv("AM_TEST_TOKEN")
+
+	redactor, err := TaskRedactor(TaskRecord{}, "", nil, []string{"AM_TEST_TOKEN=inherited-token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !redactor.Empty() {
+		t.Fatal("expected inherited env to keep output unredacted")
+	}
+	redactor, err = TaskRedactor(TaskRecord{}, "", nil, []string{"AM_TEST_TOKEN=set-by-task"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if redactor.Empty() {
+		t.Fatal("expected env set for the task to be redacted")
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe to read while a timer writes to it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,255 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// ScaffoldLanguages are the languages ScaffoldPlugin can generate.
+var ScaffoldLanguages = []string{"sh", "python", "go"}
+
+// CreateSpec validates manifest and stores it as a new spec in the scope's
+// spec dir, refusing to replace an existing one.
+func CreateSpec(manifest Manifest, repoRoot string, scope PluginScope) (string, error) {
+	data, err := ExportSpec(manifest)
+	if err != nil {
+		return "", err
+	}
+	if _, err := ParseManifest(data); err != nil {
+		return "", err
+	}
+	if manifest.Plugin.Exec == "" && specNeedsExec(manifest) {
+		return "", fmt.Errorf("spec requires plugin.exec or a command on every task")
+	}
+	destDir, err := specDir(repoRoot, scope)
+	if err != nil {
+		return "", err
+	}
+	destPath := filepath.Join(destDir, sanitizeFilename(manifest.Plugin.ID)+".json")
+	if exists(destPath) {
+		return "", fmt.Errorf("%s already exists", destPath)
+	}
+	if err := os.MkdirAll(destDir, 0o755); err != nil {
+		return "", fmt.Errorf("create spec dir: %w", err)
+	}
+	if err := os.WriteFile(destPath, data, 0o644); err != nil {
+		return "", fmt.Errorf("write spec: %w", err)
+	}
+	return destPath, nil
+}
+
+// ScaffoldPlugin writes a protocol plugin skeleton in language to the repo's
+// bin dir. It describes manifest and stubs out every task. Go plugins are a
+// source file next to an executable wrapper calling `go run`, so only the
+// wrapper is discovered. It returns the written files.
+func ScaffoldPlugin(manifest Manifest, language, repoRoot string) ([]string, error) {
+	tmpl, ok := scaffoldTemplates[language]
+	if !ok {
+		return nil, fmt.Errorf("unknown plugin language %q", language)
+	}
+	manifest.SchemaVersion = CurrentSchemaVersion
+	manifest.Plugin.Exec = ""
+	manifest.Plugin.ExecMode = ""
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode manifest: %w", err)
+	}
+	if _, err := ParseManifest(data); err != nil {
+		return nil, err
+	}
+	binDir, err := newPathConfig(repoRoot).localBin()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(binDir, sanitizeFilename(manifest.Plugin.ID))
+	files := map[string]string{path: tmpl}
+	if language == "go" {
+		files = map[string]string{path: goWrapperTemplate, path + ".go": tmpl}
+	}
+	for file := range files {
+		if exists(file) {
+			return nil, fmt.Errorf("%s already exists", file)
+		}
+	}
+	if err := os.MkdirAll(binDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create bin dir: %w", err)
+	}
+	view := struct {
+		Manifest string
+		Tasks    []TaskSpec
+	}{string(data), manifest.Tasks}
+	var written []string
+	for _, file := range []string{path, path + ".go"} {
+		text, ok := files[file]
+		if !ok {
+			continue
+		}
+		var out bytes.Buffer
+		if err := template.Must(template.New(filepath.Base(file)).Funcs(scaffoldFuncs).Parse(text)).Execute(&out, view); err != nil {
+			return nil, fmt.Errorf("render %s: %w", file, err)
+		}
+		mode := os.FileMode(0o755)
+		if filepath.Ext(file) == ".go" {
+			mode = 0o644
+		}
+		if err := os.WriteFile(file, out.Bytes(), mode); err != nil {
+			return nil, fmt.Errorf("write %s: %w", file, err)
+		}
+		written = append(written, file)
+	}
+	return written, nil
+}
+
+var scaffoldFuncs = template.FuncMap{
+	// ident turns a task name into an identifier.
+	"ident": func(name string) string {
+		return strings.NewReplacer("-", "_", ".", "_").Replace(sanitizeFilename(name))
+	},
+}
+
+var scaffoldTemplates = map[string]string{
+	"sh": `#!/bin/sh
+# automate-me protocol plugin: "describe" prints the manifest, "run <task>"
+# runs a task with {"args": ..., "ctx": ...} on stdin.
+set -e
+
+describe() {
+	cat <<'MANIFEST'
+{{.Manifest}}
+MANIFEST
+}
+
+run() {
+	payload=$(cat)
+	case "$1" in
+{{- range .Tasks}}
+	'{{.Name}}')
+		echo "TODO: implement {{.Name}} with $payload"
+		;;
+{{- end}}
+	*)
+		echo "unknown task: $1" >&2
+		exit 1
+		;;
+	esac
+}
+
+case "$1" in
+describe) describe ;;
+run) run "$2" ;;
+*)
+	echo "usage: $0 describe|run <task>" >&2
+	exit 1
+	;;
+esac
+`,
+	"python": `#!/usr/bin/env python3
+# automate-me protocol plugin: "describe" prints the manifest, "run <task>"
+# runs a task with {"args": ..., "ctx": ...} on stdin.
+import json
+import sys
+
+MANIFEST = json.loads({{printf "%q" .Manifest}})
+{{range .Tasks}}
+
+def task_{{ident .Name}}(args, ctx):
+    print({{printf "%q" (printf "TODO: implement %s with" .Name)}}, args)
+{{end}}
+
+TASKS = {
+{{- range .Tasks}}
+    {{printf "%q" .Name}}: task_{{ident .Name}},
+{{- end}}
+}
+
+
+def run(task):
+    if task not in TASKS:
+        print(f"unknown task: {task}", file=sys.stderr)
+        sys.exit(1)
+    raw = sys.stdin.read()
+    payload = json.loads(raw) if raw.strip() else {}
+    TASKS[task](payload.get("args", {}), payload.get("ctx", {}))
+
+
+def usage():
+    print("usage: plugin describe|run <task>", file=sys.stderr)
+    sys.exit(1)
+
+
+if __name__ == "__main__":
+    if len(sys.argv) < 2:
+        usage()
+    if sys.argv[1] == "describe":
+        print(json.dumps(MANIFEST))
+    elif sys.argv[1] == "run" and len(sys.argv) > 2:
+        run(sys.argv[2])
+    else:
+        usage()
+`,
+	"go": `// automate-me protocol plugin, run through the wrapper next to this file:
+// "describe" prints the manifest, "run <task>" runs a task with
+// {"args": ..., "ctx": ...} on stdin.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+const manifest = {{printf "%q" .Manifest}}
+
+type payload struct {
+	Args map[string]any ` + "`json:\"args\"`" + `
+	Ctx  map[string]any ` + "`json:\"ctx\"`" + `
+}
+
+func main() {
+	switch {
+	case len(os.Args) > 1 && os.Args[1] == "describe":
+		fmt.Println(manifest)
+	case len(os.Args) > 2 && os.Args[1] == "run":
+		if err := run(os.Args[2]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, "usage: plugin describe|run <task>")
+		os.Exit(1)
+	}
+}
+
+func run(task string) error {
+	var input payload
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &input); err != nil {
+			return err
+		}
+	}
+	switch task {
+{{- range .Tasks}}
+	case {{printf "%q" .Name}}:
+		fmt.Println({{printf "%q" (printf "TODO: implement %s with" .Name)}}, input.Args)
+{{- end}}
+	default:
+		return fmt.Errorf("unknown task: %s", task)
+	}
+	return nil
+}
+`,
+}
+
+const goWrapperTemplate = `#!/bin/sh
+exec go run "$0.go" "$@"
+`
@@ -0,0 +1,101 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+const defaultInterpreter = "sh"
+
+// scriptCommand writes an inline script task to a temp file and returns the
+// argv running it and a cleanup func removing the file.
+func scriptCommand(task TaskRecord) ([]string, func(), error) {
+	interpreter := strings.Fields(task.Task.Interpreter)
+	if len(interpreter) == 0 {
+		interpreter = []string{defaultInterpreter}
+	}
+	file, err := os.CreateTemp("", "automate-me-script-*")
+	if err != nil {
+		return nil, nil, fmt.Errorf("create script file: %w", err)
+	}
+	cleanup := func() { os.Remove(file.Name()) }
+	if _, err := file.WriteString(task.Task.Script); err != nil {
+		file.Close()
+		cleanup()
+		return nil, nil, fmt.Errorf("write script file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("write script file: %w", err)
+	}
+	return append(interpreter, file.Name()), cleanup, nil
+}
+
+// ArgEnvName is the env var an input is exported as for script tasks.
+func ArgEnvName(name string) string {
+	return inputEnvName("AUTOMATE_ME_ARG_", name)
+}
+
+// scriptArgEnv exports every declared input, empty when not collected.
+// Lists and kv values use the same comma-separated form as --arg.
+func scriptArgEnv(inputs []InputSpec, args map[string]any) []string {
+	values := make(map[string]string)
+	for _, input := range inputs {
+		values[input.Name] = ""
+	}
+	for name, value := range args {
+		switch v := commandValue(value).(type) {
+		case []string:
+			values[name] = strings.Join(v, ",")
+		case string:
+			values[name] = v
+		}
+	}
+	var env []string
+	for name, value := range values {
+		env = append(env, ArgEnvName(name)+"="+value)
+	}
+	sort.Strings(env)
+	return env
+}
+
+// secretArgEnv exports the secret inputs of a direct command, which cannot
+// reference them in its arguments.
+func secretArgEnv(inputs []InputSpec, args map[string]any) []string {
+	var secrets []InputSpec
+	for _, input := range inputs {
+		if input.Secret {
+			secrets = append(secrets, input)
+		}
+	}
+	if len(secrets) == 0 {
+		return nil
+	}
+	return scriptArgEnv(secrets, pickArgs(args, secrets))
+}
+
+func pickArgs(args map[string]any, inputs []InputSpec) map[string]any {
+	out := make(map[string]any)
+	for _, input := range inputs {
+		if value, ok := args[input.Name]; ok {
+			out[input.Name] = value
+		}
+	}
+	return out
+}
+
+// specNeedsExec reports whether a spec relies on plugin.exec: protocol specs
+// and specs where some task has neither its own command nor a script.
+func specNeedsExec(manifest Manifest) bool {
+	if len(manifest.Tasks) == 0 || strings.EqualFold(manifest.Plugin.ExecMode, "protocol") {
+		return true
+	}
+	for _, task := range manifest.Tasks {
+		if len(task.Command) == 0 && task.Script == "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestRunPluginTaskScript(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping shell script test on windows")
+	}
+	base := t.TempDir()
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	outputFile := filepath.Join(base, "out.txt")
+	os.Setenv("OUTPUT_FILE", outputFile)
+	defer os.Unsetenv("OUTPUT_FILE")
+
+	task := TaskRecord{
+		PluginID:   "repo",
+		DirectExec: true,
+		Task: TaskSpec{
+			Name:   "greet",
+			Script: "echo \"$AUTOMATE_ME_ARG_NAME|$AUTOMATE_ME_ARG_TAGS|$AUTOMATE_ME_ARG_EXTRA_FLAG|$0\" > \"$OUTPUT_FILE\"\n",
+			Inputs: []InputSpec{
+				{Name: "name", Type: "string"},
+				{Name: "tags", Type: "list"},
+				{Name: "extra-flag", Type: "bool"},
+			},
+		},
+	}
+	args := map[string]any{"name": "it's me", "tags": []string{"a", "b"}}
+	if err := RunPluginTask(task, base, base, args); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(strings.TrimSpace(string(data)), "|")
+	if len(parts) != 4 || parts[0] != "it's me" || parts[1] != "a,b" || parts[2] != "" {
+		t.Fatalf("unexpected script output: %q", data)
+	}
+	if _, err := os.Stat(parts[3]); !os.IsNotExist(err) {
+		t.Fatalf("expected script file %s to be removed", parts[3])
+	}
+}
+
+func TestSpecNeedsExec(t *testing.T) {
+	scripted := Manifest{Tasks: []TaskSpec{{Name: "a", Script: "true"}, {Name: "b", Command: []string{"ls"}}}}
+	if specNeedsExec(scripted) {
+		t.Fatal("expected script and command tasks to not need plugin.exec")
+	}
+	scripted.Tasks = append(scripted.Tasks, TaskSpec{Name: "c"})
+	if !specNeedsExec(scripted) {
+		t.Fatal("expected plain task to need plugin.exec")
+	}
+}
+
+func TestParseManifestRejectsProtocolScript(t *testing.T) {
+	data := []byte(`{"schemaVersion":2,"plugin":{"id":"p","exec":"bin/p","mode":"protocol"},"tasks":[{"name":"t","script":"true"}]}`)
+	if _, err := ParseManifest(data); err == nil {
+		t.Fatal("expected script in protocol mode to be rejected")
+	}
+}
@@ -0,0 +1,108 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// SecretProviderEnv names the command used to look up secretFrom.key, e.g.
+// "pass show". The key is appended as the last argument and the secret is
+// read from stdout.
+const SecretProviderEnv = "AUTOMATE_ME_SECRET_PROVIDER"
+
+// ResolveSecret looks up a secret input without prompting. Sources are tried
+// in order: secretFrom.env, secretFrom.file, secretFrom.key through the
+// provider command, and finally AUTOMATE_ME_SECRET_<NAME>.
+func ResolveSecret(input InputSpec, repoRoot string) (string, bool, error) {
+	if source := input.SecretFrom; source != nil {
+		if source.Env != "" {
+			if value, ok := os.LookupEnv(source.Env); ok {
+				return value, true, nil
+			}
+		}
+		if source.File != "" {
+			value, err := readSecretFile(source.File, repoRoot)
+			if err != nil {
+				return "", false, fmt.Errorf("secret %s: %w", input.Name, err)
+			}
+			return value, true, nil
+		}
+		if source.Key != "" {
+			value, err := lookupSecretKey(source.Key)
+			if err != nil {
+				return "", false, fmt.Errorf("secret %s: %w", input.Name, err)
+			}
+			return value, true, nil
+		}
+	}
+	if value, ok := os.LookupEnv(SecretEnvName(input.Name)); ok {
+		return value, true, nil
+	}
+	return "", false, nil
+}
+
+// SecretEnvName is the conventional env var for a secret input.
+func SecretEnvName(name string) string {
+	return inputEnvName("AUTOMATE_ME_SECRET_", name)
+}
+
+// inputEnvName upper-cases name and replaces anything but letters and
+// digits with underscores.
+func inputEnvName(prefix, name string) string {
+	var out strings.Builder
+	out.WriteString(prefix)
+	for _, r := range strings.ToUpper(name) {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			out.WriteRune(r)
+		default:
+			out.WriteRune('_')
+		}
+	}
+	return out.String()
+}
+
+// WithoutSecrets drops secret values from args so they are never kept as
+// defaults for a later run.
+func WithoutSecrets(inputs []InputSpec, args map[string]any) map[string]any {
+	out := make(map[string]any, len(args))
+	for name, value := range args {
+		if input, ok := findInput(inputs, name); ok && input.Secret {
+			continue
+		}
+		out[name] = value
+	}
+	return out
+}
+
+func readSecretFile(path, repoRoot string) (string, error) {
+	path = expandHome(path)
+	if !filepath.IsAbs(path) && repoRoot != "" {
+		path = filepath.Join(repoRoot, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read secret file: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+func lookupSecretKey(key string) (string, error) {
+	provider := strings.Fields(os.Getenv(SecretProviderEnv))
+	if len(provider) == 0 {
+		return "", fmt.Errorf("secretFrom.key requires %s", SecretProviderEnv)
+	}
+	cmd := exec.Command(provider[0], append(provider[1:], key)...)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("secret provider: %w", err)
+	}
+	value, _, _ := strings.Cut(stdout.String(), "\n")
+	return strings.TrimRight(value, "\r"), nil
+}
@@ -0,0 +1,76 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestResolveSecretSources(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping shell script test on windows")
+	}
+	base := t.TempDir()
+	if err := os.WriteFile(filepath.Join(base, "token.txt"), []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	provider := filepath.Join(base, "provider.sh")
+	if err := os.WriteFile(provider, []byte("#!/bin/sh\necho \"stub-$1\"\necho extra\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("TEST_TOKEN", "from-env")
+	defer os.Unsetenv("TEST_TOKEN")
+	os.Setenv(SecretProviderEnv, provider)
+	defer os.Unsetenv(SecretProviderEnv)
+	os.Setenv("AUTOMATE_ME_SECRET_API_KEY", "from-convention")
+	defer os.Unsetenv("AUTOMATE_ME_SECRET_API_KEY")
+
+	tests := []struct {
+		name  string
+		input InputSpec
+		want  string
+	}{
+		{name: "env", input: InputSpec{Name: "token", Secret: true, SecretFrom: &SecretSource{Env: "TEST_TOKEN"}}, want: "from-env"},
+		{name: "file", input: InputSpec{Name: "token", Secret: true, SecretFrom: &SecretSource{File: "token.txt"}}, want: "from-file"},
+		{name: "provider", input: InputSpec{Name: "token", Secret: true, SecretFrom: &SecretSource{Key: "npm"}}, want: "stub-npm"},
+		{name: "convention", input: InputSpec{Name: "api-key", Secret: true}, want: "from-convention"},
+	}
+	for _, tt := range tests {
+		value, ok, err := ResolveSecret(tt.input, base)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !ok || value != tt.want {
+			t.Fatalf("%s: expected %q, got %q (found %v)", tt.name, tt.want, value, ok)
+		}
+	}
+
+	if _, ok, err := ResolveSecret(InputSpec{Name: "other", Secret: true}, base); err != nil || ok {
+		t.Fatalf("expected no secret, got found=%v err=%v", ok, err)
+	}
+}
+
+func TestSecretsNotAcceptedAsArgsOrKept(t *testing.T) {
+	inputs := []InputSpec{{Name: "user", Type: "string"}, {Name: "token", Type: "string", Secret: true}}
+	if _, err := ParseArgs(inputs, map[string]string{"token": "x"}, "", ""); err == nil {
+		t.Fatal("expected secret arg to be rejected")
+	}
+	kept := WithoutSecrets(inputs, map[string]any{"user": "me", "token": "x"})
+	if _, ok := kept["token"]; ok || kept["user"] != "me" {
+		t.Fatalf("unexpected kept args: %v", kept)
+	}
+}
+
+func TestResolveArgsResolvesSecrets(t *testing.T) {
+	os.Setenv("AUTOMATE_ME_SECRET_TOKEN", "s3cr3t")
+	defer os.Unsetenv("AUTOMATE_ME_SECRET_TOKEN")
+	inputs := []InputSpec{{Name: "token", Type: "string", Secret: true, Required: true, Default: "ignored"}}
+	args, err := ResolveArgs(inputs, nil, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args["token"] != "s3cr3t" {
+		t.Fatalf("unexpected token: %v", args["token"])
+	}
+}
@@ -0,0 +1,336 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// specSourcesFileName is the metadata file in a spec dir mapping each stored
+// spec file to the source it was imported from.
+const specSourcesFileName = ".sources.json"
+
+// SpecSource records where a stored spec was imported from and the SHA-256
+// of the imported content.
+type SpecSource struct {
+	Source     string    `json:"source"`
+	SHA256     string    `json:"sha256"`
+	ImportedAt time.Time `json:"importedAt"`
+}
+
+// SpecImport is a spec read from its source, ready to be stored at
+// DestPath. Existing holds the current spec with the same id, if any, which
+// is stored at ExistingPath and replaced by the import.
+type SpecImport struct {
+	Source         string
+	ID             string
+	DestPath       string
+	Data           []byte
+	Existing       []byte
+	ExistingPath   string
+	ExistingSource string
+}
+
+// Collision reports whether storing the import would replace a spec that
+// came from a different source.
+func (i SpecImport) Collision() bool {
+	return i.Existing != nil && i.ExistingSource != i.Source
+}
+
+// Changed reports whether the import differs from the stored spec.
+func (i SpecImport) Changed() bool {
+	return i.Existing == nil || string(i.Existing) != string(i.Data)
+}
+
+// ExpandSpecSources turns import arguments into spec sources: directories
+// are replaced by the spec files (.json, .yaml, .yml, .toml) they contain, URLs are kept as given and
+// local files are made absolute.
+func ExpandSpecSources(args []string) ([]string, error) {
+	var sources []string
+	for _, arg := range args {
+		if isSpecURL(arg) {
+			sources = append(sources, arg)
+			continue
+		}
+		path, err := filepath.Abs(arg)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("read spec: %w", err)
+		}
+		if !info.IsDir() {
+			sources = append(sources, path)
+			continue
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("read spec dir %s: %w", path, err)
+		}
+		var found []string
+		for _, entry := range entries {
+			if entry.IsDir() || !isSpecFile(entry.Name()) {
+				continue
+			}
+			found = append(found, filepath.Join(path, entry.Name()))
+		}
+		if len(found) == 0 {
+			return nil, fmt.Errorf("no specs in %s", path)
+		}
+		sort.Strings(found)
+		sources = append(sources, found...)
+	}
+	return sources, nil
+}
+
+// PlanSpecImport reads and validates a spec from source (a file path, a
+// file:// URL or an http(s) URL) and resolves where it would be stored. The
+// source's extension selects its format, JSON by default, which is kept.
+func PlanSpecImport(source, repoRoot string, scope PluginScope) (SpecImport, error) {
+	data, err := readSpecSource(source)
+	if err != nil {
+		return SpecImport{}, err
+	}
+	format := ManifestFormat(source)
+	if format == "" {
+		format = FormatJSON
+	}
+	manifest, err := ParseManifestFormat(data, format)
+	if err != nil {
+		return SpecImport{}, fmt.Errorf("%s: %w", source, err)
+	}
+	if manifest.Plugin.Exec == "" && specNeedsExec(manifest) {
+		return SpecImport{}, fmt.Errorf("imported spec requires plugin.exec")
+	}
+	destDir, err := specDir(repoRoot, scope)
+	if err != nil {
+		return SpecImport{}, err
+	}
+	name := sanitizeFilename(manifest.Plugin.ID)
+	plan := SpecImport{
+		Source:   source,
+		ID:       manifest.Plugin.ID,
+		DestPath: filepath.Join(destDir, name+"."+format),
+		Data:     data,
+	}
+	plan.ExistingPath = plan.DestPath
+	records, _, err := readSpecDir(destDir, scope)
+	if err != nil {
+		return SpecImport{}, err
+	}
+	for _, record := range records {
+		if record.Manifest.Plugin.ID == manifest.Plugin.ID {
+			plan.ExistingPath = record.SpecPath
+			break
+		}
+	}
+	existing, err := os.ReadFile(plan.ExistingPath)
+	switch {
+	case err == nil:
+		sources, err := readSpecSources(destDir)
+		if err != nil {
+			return SpecImport{}, err
+		}
+		plan.Existing = existing
+		plan.ExistingSource = sources[filepath.Base(plan.ExistingPath)].Source
+	case os.IsNotExist(err):
+		plan.ExistingPath = ""
+	default:
+		return SpecImport{}, fmt.Errorf("read spec: %w", err)
+	}
+	return plan, nil
+}
+
+// Write stores the spec and records its source.
+func (i SpecImport) Write() error {
+	destDir := filepath.Dir(i.DestPath)
+	if err := os.MkdirAll(destDir, 0o755); err != nil {
+		return fmt.Errorf("create spec dir: %w", err)
+	}
+	if err := os.WriteFile(i.DestPath, i.Data, 0o644); err != nil {
+		return fmt.Errorf("write spec: %w", err)
+	}
+	sources, err := readSpecSources(destDir)
+	if err != nil {
+		return err
+	}
+	if i.ExistingPath != "" && i.ExistingPath != i.DestPath {
+		if err := os.Remove(i.ExistingPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove spec: %w", err)
+		}
+		delete(sources, filepath.Base(i.ExistingPath))
+	}
+	sum := sha256.Sum256(i.Data)
+	sources[filepath.Base(i.DestPath)] = SpecSource{
+		Source:     i.Source,
+		SHA256:     hex.EncodeToString(sum[:]),
+		ImportedAt: time.Now().UTC(),
+	}
+	return writeSpecSources(destDir, sources)
+}
+
+// SpecUpdates re-reads the source of every spec imported into the scope's
+// spec dir and returns those whose source changed since import. Sources
+// that can no longer be read are returned in failed.
+func SpecUpdates(repoRoot string, scope PluginScope) (updates []SpecImport, failed map[string]error, err error) {
+	destDir, err := specDir(repoRoot, scope)
+	if err != nil {
+		return nil, nil, err
+	}
+	sources, err := readSpecSources(destDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	failed = make(map[string]error)
+	for _, name := range names {
+		recorded := sources[name]
+		if !exists(filepath.Join(destDir, name)) {
+			continue
+		}
+		data, err := readSpecSource(recorded.Source)
+		if err != nil {
+			failed[recorded.Source] = err
+			continue
+		}
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) == recorded.SHA256 {
+			continue
+		}
+		plan, err := PlanSpecImport(recorded.Source, repoRoot, scope)
+		if err != nil {
+			failed[recorded.Source] = err
+			continue
+		}
+		updates = append(updates, plan)
+	}
+	return updates, failed, nil
+}
+
+func readSpecSource(source string) ([]byte, error) {
+	if !isSpecURL(source) {
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return nil, fmt.Errorf("read spec: %w", err)
+		}
+		return data, nil
+	}
+	parsed, err := url.Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid spec url %s: %w", source, err)
+	}
+	if parsed.Scheme == "file" {
+		data, err := os.ReadFile(filepath.FromSlash(parsed.Path))
+		if err != nil {
+			return nil, fmt.Errorf("read spec: %w", err)
+		}
+		return data, nil
+	}
+	client := http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(source)
+	if err != nil {
+		return nil, fmt.Errorf("fetch spec: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch spec %s: %s", source, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("fetch spec %s: %w", source, err)
+	}
+	return data, nil
+}
+
+// isSpecFile reports whether a file in a spec dir holds a spec.
+func isSpecFile(name string) bool {
+	return ManifestFormat(name) != "" && name != specSourcesFileName
+}
+
+func isSpecURL(source string) bool {
+	for _, scheme := range []string{"file://", "http://", "https://"} {
+		if strings.HasPrefix(strings.ToLower(source), scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+func readSpecSources(dir string) (map[string]SpecSource, error) {
+	sources := make(map[string]SpecSource)
+	path := filepath.Join(dir, specSourcesFileName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return sources, nil
+		}
+		return nil, fmt.Errorf("read spec sources: %w", err)
+	}
+	if err := json.Unmarshal(data, &sources); err != nil {
+		return nil, fmt.Errorf("invalid spec sources %s: %w", path, err)
+	}
+	return sources, nil
+}
+
+func writeSpecSources(dir string, sources map[string]SpecSource) error {
+	data, err := json.MarshalIndent(sources, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode spec sources: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, specSourcesFileName), append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write spec sources: %w", err)
+	}
+	return nil
+}
+
+// DiffLines returns a line diff of old and new, prefixing removed lines
+// with "-", added lines with "+" and unchanged ones with " ".
+func DiffLines(old, new []byte) string {
+	a := strings.Split(strings.TrimSuffix(string(old), "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(string(new), "\n"), "\n")
+	// lcs[i][j] is the longest common subsequence of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var out strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			out.WriteString(" " + a[i] + "\n")
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			out.WriteString("-" + a[i] + "\n")
+			i++
+		default:
+			out.WriteString("+" + b[j] + "\n")
+			j++
+		}
+	}
+	return out.String()
+}
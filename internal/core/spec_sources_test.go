@@ -0,0 +1,115 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func specJSON(id, title string) string {
+	return `{"schemaVersion": 1, "plugin": {"id": "` + id + `", "title": "` + title + `", "exec": "/bin/echo"}, "tasks": [{"name": "test"}]}`
+}
+
+func TestExpandSpecSourcesDirectory(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.json", "a.json", "notes.txt", specSourcesFileName} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("{}"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sources, err := ExpandSpecSources([]string{dir, "https://example.com/spec.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json"), "https://example.com/spec.json"}
+	if len(sources) != len(want) {
+		t.Fatalf("expected %v, got %v", want, sources)
+	}
+	for i := range want {
+		if sources[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, sources)
+		}
+	}
+}
+
+func TestPlanSpecImportDetectsCollision(t *testing.T) {
+	base := t.TempDir()
+	repo := filepath.Join(base, "repo")
+	first := filepath.Join(base, "first.json")
+	second := filepath.Join(base, "second.json")
+	if err := os.WriteFile(first, []byte(specJSON("repo", "First")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(second, []byte(specJSON("repo", "Second")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ImportSpecFile(first, repo, ScopeLocal); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := PlanSpecImport(second, repo, ScopeLocal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !plan.Collision() || plan.ExistingSource != first {
+		t.Fatalf("expected collision with %s, got %+v", first, plan)
+	}
+	plan, err = PlanSpecImport(first, repo, ScopeLocal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Collision() || plan.Changed() {
+		t.Fatalf("expected re-import of the same source to be a no-op, got %+v", plan)
+	}
+}
+
+func TestSpecUpdatesFromURL(t *testing.T) {
+	base := t.TempDir()
+	repo := filepath.Join(base, "repo")
+	body := specJSON("remote", "Remote")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	dest, err := ImportSpecFile(server.URL+"/spec.json", repo, ScopeLocal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	updates, failed, err := SpecUpdates(repo, ScopeLocal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updates) != 0 || len(failed) != 0 {
+		t.Fatalf("expected no updates, got %+v %v", updates, failed)
+	}
+
+	body = specJSON("remote", "Remote v2")
+	updates, _, err = SpecUpdates(repo, ScopeLocal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updates) != 1 || updates[0].DestPath != dest || !updates[0].Changed() {
+		t.Fatalf("expected one update for %s, got %+v", dest, updates)
+	}
+	if err := updates[0].Write(); err != nil {
+		t.Fatal(err)
+	}
+	updates, _, err = SpecUpdates(repo, ScopeLocal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updates) != 0 {
+		t.Fatalf("expected no updates after applying, got %+v", updates)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	got := DiffLines([]byte("a\nb\nc\n"), []byte("a\nx\nc\n"))
+	want := " a\n-b\n+x\n c\n"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
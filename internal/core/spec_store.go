@@ -0,0 +1,185 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// StoredSpec is a spec in the local or global spec dir, with the source it
+// was imported from when known.
+type StoredSpec struct {
+	ID       string
+	Scope    PluginScope
+	Path     string
+	Source   string
+	Manifest Manifest
+}
+
+// ListStoredSpecs returns the valid specs of the repo's spec dir (when in a
+// repo) followed by those of the global spec dir, with diagnostics about the
+// invalid ones.
+func ListStoredSpecs(repoRoot string) ([]StoredSpec, Diagnostics, error) {
+	var scopes []PluginScope
+	if repoRoot != "" {
+		scopes = append(scopes, ScopeLocal)
+	}
+	scopes = append(scopes, ScopeGlobal)
+	var specs []StoredSpec
+	var diagnostics Diagnostics
+	for _, scope := range scopes {
+		dir, err := specDir(repoRoot, scope)
+		if err != nil {
+			return nil, nil, err
+		}
+		records, dirDiagnostics, err := readSpecDir(dir, scope)
+		if err != nil {
+			return nil, nil, err
+		}
+		diagnostics = append(diagnostics, dirDiagnostics...)
+		sources, err := readSpecSources(dir)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, record := range records {
+			specs = append(specs, StoredSpec{
+				ID:       record.Manifest.Plugin.ID,
+				Scope:    scope,
+				Path:     record.SpecPath,
+				Source:   sources[filepath.Base(record.SpecPath)].Source,
+				Manifest: record.Manifest,
+			})
+		}
+	}
+	return specs, diagnostics, nil
+}
+
+// FindStoredSpec returns the spec with the given plugin id, preferring the
+// local one unless scope restricts the search ("" searches both).
+func FindStoredSpec(repoRoot, id string, scope PluginScope) (StoredSpec, error) {
+	specs, _, err := ListStoredSpecs(repoRoot)
+	if err != nil {
+		return StoredSpec{}, err
+	}
+	for _, spec := range specs {
+		if spec.ID == id && (scope == "" || spec.Scope == scope) {
+			return spec, nil
+		}
+	}
+	if scope != "" {
+		return StoredSpec{}, fmt.Errorf("no %s spec with id %s", scope, id)
+	}
+	return StoredSpec{}, fmt.Errorf("no spec with id %s", id)
+}
+
+// SaveStoredSpec validates data and replaces the stored spec with it. The
+// plugin id may not change, since it names the file.
+func SaveStoredSpec(spec StoredSpec, data []byte) error {
+	manifest, err := ParseManifestFormat(data, ManifestFormat(spec.Path))
+	if err != nil {
+		return err
+	}
+	if manifest.Plugin.ID != spec.ID {
+		return fmt.Errorf("plugin id changed from %s to %s; import it as a new spec instead", spec.ID, manifest.Plugin.ID)
+	}
+	if manifest.Plugin.Exec == "" && specNeedsExec(manifest) {
+		return fmt.Errorf("spec requires plugin.exec")
+	}
+	if err := os.WriteFile(spec.Path, data, 0o644); err != nil {
+		return fmt.Errorf("write spec: %w", err)
+	}
+	return nil
+}
+
+// RemoveStoredSpec deletes the spec file and its recorded source.
+func RemoveStoredSpec(spec StoredSpec) error {
+	if err := os.Remove(spec.Path); err != nil {
+		return fmt.Errorf("remove spec: %w", err)
+	}
+	dir := filepath.Dir(spec.Path)
+	sources, err := readSpecSources(dir)
+	if err != nil {
+		return err
+	}
+	if _, ok := sources[filepath.Base(spec.Path)]; !ok {
+		return nil
+	}
+	delete(sources, filepath.Base(spec.Path))
+	return writeSpecSources(dir, sources)
+}
+
+// MoveStoredSpec moves the spec, with its recorded source, to the spec dir
+// of scope and returns the new path. It refuses to replace a spec there.
+func MoveStoredSpec(spec StoredSpec, repoRoot string, scope PluginScope) (string, error) {
+	if spec.Scope == scope {
+		return "", fmt.Errorf("spec %s is already %s", spec.ID, scope)
+	}
+	destDir, err := specDir(repoRoot, scope)
+	if err != nil {
+		return "", err
+	}
+	destPath := filepath.Join(destDir, filepath.Base(spec.Path))
+	if exists(destPath) {
+		return "", fmt.Errorf("%s already exists", destPath)
+	}
+	data, err := os.ReadFile(spec.Path)
+	if err != nil {
+		return "", fmt.Errorf("read spec: %w", err)
+	}
+	if err := os.MkdirAll(destDir, 0o755); err != nil {
+		return "", fmt.Errorf("create spec dir: %w", err)
+	}
+	if err := os.WriteFile(destPath, data, 0o644); err != nil {
+		return "", fmt.Errorf("write spec: %w", err)
+	}
+	sources, err := readSpecSources(filepath.Dir(spec.Path))
+	if err != nil {
+		return "", err
+	}
+	if source, ok := sources[filepath.Base(spec.Path)]; ok {
+		destSources, err := readSpecSources(destDir)
+		if err != nil {
+			return "", err
+		}
+		destSources[filepath.Base(destPath)] = source
+		if err := writeSpecSources(destDir, destSources); err != nil {
+			return "", err
+		}
+	}
+	if err := RemoveStoredSpec(spec); err != nil {
+		return "", err
+	}
+	return destPath, nil
+}
+
+// ExportSpec returns the manifest re-encoded at CurrentSchemaVersion, with
+// defaults filled in and unknown fields dropped.
+func ExportSpec(manifest Manifest) ([]byte, error) {
+	manifest.SchemaVersion = CurrentSchemaVersion
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode spec: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// MigrateStoredSpec rewrites a spec written in an older schemaVersion at
+// CurrentSchemaVersion with MigrateManifest and returns the version it had.
+// The hash recorded in .sources.json stays the source's, so an unchanged
+// source is not reported by SpecUpdates. Specs that are already current are
+// left untouched.
+func MigrateStoredSpec(spec StoredSpec) (int, error) {
+	data, err := os.ReadFile(spec.Path)
+	if err != nil {
+		return 0, fmt.Errorf("read spec: %w", err)
+	}
+	migrated, version, err := MigrateManifest(data, ManifestFormat(spec.Path))
+	if err != nil || version == CurrentSchemaVersion {
+		return version, err
+	}
+	if err := os.WriteFile(spec.Path, migrated, 0o644); err != nil {
+		return 0, fmt.Errorf("write spec: %w", err)
+	}
+	return version, nil
+}
@@ -0,0 +1,89 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMoveAndRemoveStoredSpec(t *testing.T) {
+	base := t.TempDir()
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	repo := filepath.Join(base, "repo")
+	src := filepath.Join(base, "spec.json")
+	if err := os.WriteFile(src, []byte(specJSON("repo", "Repo")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ImportSpecFile(src, repo, ScopeLocal); err != nil {
+		t.Fatal(err)
+	}
+
+	spec, err := FindStoredSpec(repo, "repo", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.Scope != ScopeLocal || spec.Source != src {
+		t.Fatalf("unexpected stored spec %+v", spec)
+	}
+	moved, err := MoveStoredSpec(spec, repo, ScopeGlobal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec, err = FindStoredSpec(repo, "repo", ScopeGlobal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.Path != moved || spec.Source != src {
+		t.Fatalf("expected moved spec to keep its source, got %+v", spec)
+	}
+	if _, err := FindStoredSpec(repo, "repo", ScopeLocal); err == nil {
+		t.Fatal("expected local spec to be gone after move")
+	}
+
+	if err := RemoveStoredSpec(spec); err != nil {
+		t.Fatal(err)
+	}
+	specs, _, err := ListStoredSpecs(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(specs) != 0 {
+		t.Fatalf("expected no specs after remove, got %+v", specs)
+	}
+	sources, err := readSpecSources(filepath.Dir(moved))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sources) != 0 {
+		t.Fatalf("expected source record removed, got %+v", sources)
+	}
+}
+
+func TestSaveStoredSpecValidates(t *testing.T) {
+	base := t.TempDir()
+	path := filepath.Join(base, "repo.json")
+	original := specJSON("repo", "Repo")
+	if err := os.WriteFile(path, []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	spec := StoredSpec{ID: "repo", Scope: ScopeLocal, Path: path}
+
+	if err := SaveStoredSpec(spec, []byte("{")); err == nil {
+		t.Fatal("expected invalid JSON to be rejected")
+	}
+	if err := SaveStoredSpec(spec, []byte(specJSON("other", "Other"))); err == nil || !strings.Contains(err.Error(), "plugin id changed") {
+		t.Fatalf("expected id change to be rejected, got %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != original {
+		t.Fatalf("expected rejected edits to leave the spec untouched, got %s", data)
+	}
+	if err := SaveStoredSpec(spec, []byte(specJSON("repo", "Renamed"))); err != nil {
+		t.Fatal(err)
+	}
+}
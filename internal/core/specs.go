@@ -7,90 +7,91 @@ import (
 	"strings"
 )
 
+// ImportSpecFile stores the spec at srcPath in the scope's spec dir,
+// replacing any spec with the same id, and returns the stored path.
 func ImportSpecFile(srcPath, repoRoot string, scope PluginScope) (string, error) {
-	data, err := os.ReadFile(srcPath)
-	if err != nil {
-		return "", fmt.Errorf("read spec: %w", err)
+	if !isSpecURL(srcPath) {
+		abs, err := filepath.Abs(srcPath)
+		if err != nil {
+			return "", err
+		}
+		srcPath = abs
 	}
-	manifest, err := ParseManifest(data)
+	plan, err := PlanSpecImport(srcPath, repoRoot, scope)
 	if err != nil {
 		return "", err
 	}
-	if manifest.Plugin.Exec == "" {
-		return "", fmt.Errorf("imported spec requires plugin.exec")
-	}
-
-	destDir, err := specDir(repoRoot, scope)
-	if err != nil {
+	if err := plan.Write(); err != nil {
 		return "", err
 	}
-	if err := os.MkdirAll(destDir, 0o755); err != nil {
-		return "", fmt.Errorf("create spec dir: %w", err)
-	}
-	fileName := sanitizeFilename(manifest.Plugin.ID) + ".json"
-	destPath := filepath.Join(destDir, fileName)
-	if err := os.WriteFile(destPath, data, 0o644); err != nil {
-		return "", fmt.Errorf("write spec: %w", err)
-	}
-	return destPath, nil
+	return plan.DestPath, nil
 }
 
-func loadSpecs(repoRoot string) ([]PluginRecord, error) {
+func loadSpecs(repoRoot string) ([]PluginRecord, Diagnostics, error) {
 	var records []PluginRecord
+	var diagnostics Diagnostics
 
-	if repoRoot != "" {
-		localDir, err := specDir(repoRoot, ScopeLocal)
+	for depth, root := range RepoLevels(repoRoot) {
+		localDir, err := specDir(root, ScopeLocal)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
-		localSpecs, err := readSpecDir(localDir, ScopeLocal)
+		localSpecs, localDiagnostics, err := readSpecDir(localDir, LevelScope(depth))
 		if err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+		for i := range localSpecs {
+			localSpecs[i].Root = root
 		}
 		records = append(records, localSpecs...)
+		diagnostics = append(diagnostics, localDiagnostics...)
 	}
 
 	globalDir, err := specDir(repoRoot, ScopeGlobal)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	globalSpecs, err := readSpecDir(globalDir, ScopeGlobal)
+	globalSpecs, globalDiagnostics, err := readSpecDir(globalDir, ScopeGlobal)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	records = append(records, globalSpecs...)
+	diagnostics = append(diagnostics, globalDiagnostics...)
 
-	return records, nil
+	return records, diagnostics, nil
 }
 
-func readSpecDir(dir string, scope PluginScope) ([]PluginRecord, error) {
+// readSpecDir loads the specs of dir, reporting the ones that are invalid
+// as diagnostics.
+func readSpecDir(dir string, scope PluginScope) ([]PluginRecord, Diagnostics, error) {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, nil
+			return nil, nil, nil
 		}
-		return nil, fmt.Errorf("read spec dir %s: %w", dir, err)
+		return nil, nil, fmt.Errorf("read spec dir %s: %w", dir, err)
 	}
 	var records []PluginRecord
+	var diagnostics Diagnostics
 	for _, entry := range entries {
 		if entry.IsDir() {
 			continue
 		}
-		if !strings.HasSuffix(strings.ToLower(entry.Name()), ".json") {
+		if !isSpecFile(entry.Name()) {
 			continue
 		}
 		path := filepath.Join(dir, entry.Name())
 		data, err := os.ReadFile(path)
 		if err != nil {
-			return nil, fmt.Errorf("read spec %s: %w", path, err)
+			return nil, nil, fmt.Errorf("read spec %s: %w", path, err)
 		}
-		manifest, err := ParseManifest(data)
+		manifest, err := ParseManifestFormat(data, ManifestFormat(path))
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "warning: invalid spec %s: %v\n", path, err)
+			diagnostics.Error(path, "invalid spec "+path, err)
 			continue
 		}
-		if manifest.Plugin.Exec == "" {
-			fmt.Fprintf(os.Stderr, "warning: spec %s missing plugin.exec\n", path)
+		if manifest.Plugin.Exec == "" && specNeedsExec(manifest) {
+			diagnostics.Error(path, fmt.Sprintf("spec %s missing plugin.exec", path), nil)
 			continue
 		}
 		directExec := true
@@ -102,9 +103,10 @@ func readSpecDir(dir string, scope PluginScope) ([]PluginRecord, error) {
 			Scope:      scope,
 			Manifest:   manifest,
 			DirectExec: directExec,
+			SpecPath:   path,
 		})
 	}
-	return records, nil
+	return records, diagnostics, nil
 }
 
 func specDir(repoRoot string, scope PluginScope) (string, error) {
@@ -63,7 +63,7 @@ func TestLoadSpecsExecModeProtocol(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	specs, err := loadSpecs(repo)
+	specs, _, err := loadSpecs(repo)
 	if err != nil {
 		t.Fatal(err)
 	}
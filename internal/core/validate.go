@@ -0,0 +1,102 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+)
+
+// ValidateInputValue checks a typed value against the declarative rules of
+// input (pattern, min/max, minItems/maxItems, mustExist/dir/file).
+func ValidateInputValue(input InputSpec, value any) error {
+	if value == nil {
+		return nil
+	}
+	switch input.Type {
+	case "string", "text":
+		return validatePattern(input, fmt.Sprint(value))
+	case "int", "float":
+		return validateRange(input, value)
+	case "multienum", "list":
+		return validateItems(input, value)
+	case "path":
+		return validatePath(input, fmt.Sprint(value))
+	}
+	return nil
+}
+
+func validatePattern(input InputSpec, value string) error {
+	if input.Pattern == "" {
+		return nil
+	}
+	re, err := regexp.Compile(input.Pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern for %s: %w", input.Name, err)
+	}
+	if !re.MatchString(value) {
+		return fmt.Errorf("%s must match pattern %s", input.Name, input.Pattern)
+	}
+	return nil
+}
+
+func validateRange(input InputSpec, value any) error {
+	number, ok := toFloat(value)
+	if !ok {
+		return fmt.Errorf("invalid number for %s", input.Name)
+	}
+	if input.Min != nil && number < *input.Min {
+		return fmt.Errorf("%s must be >= %v", input.Name, *input.Min)
+	}
+	if input.Max != nil && number > *input.Max {
+		return fmt.Errorf("%s must be <= %v", input.Name, *input.Max)
+	}
+	return nil
+}
+
+func validateItems(input InputSpec, value any) error {
+	count := 0
+	switch list := value.(type) {
+	case []string:
+		count = len(list)
+	case []any:
+		count = len(list)
+	}
+	if input.MinItems != nil && count < *input.MinItems {
+		return fmt.Errorf("%s needs at least %d items", input.Name, *input.MinItems)
+	}
+	if input.MaxItems != nil && count > *input.MaxItems {
+		return fmt.Errorf("%s allows at most %d items", input.Name, *input.MaxItems)
+	}
+	return nil
+}
+
+func validatePath(input InputSpec, value string) error {
+	if !input.MustExist && !input.Dir && !input.File {
+		return nil
+	}
+	info, err := os.Stat(value)
+	if err != nil {
+		return fmt.Errorf("%s does not exist: %s", input.Name, value)
+	}
+	if input.Dir && !info.IsDir() {
+		return fmt.Errorf("%s is not a directory: %s", input.Name, value)
+	}
+	if input.File && info.IsDir() {
+		return fmt.Errorf("%s is not a file: %s", input.Name, value)
+	}
+	return nil
+}
+
+func toFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		number, err := strconv.ParseFloat(v, 64)
+		return number, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,60 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateInputValueRules(t *testing.T) {
+	min, max := 1.0, 10.0
+	minItems, maxItems := 1, 2
+	base := t.TempDir()
+	file := filepath.Join(base, "file.txt")
+	if err := os.WriteFile(file, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		input InputSpec
+		value any
+		ok    bool
+	}{
+		{name: "pattern ok", input: InputSpec{Name: "v", Type: "string", Pattern: `^v\d+$`}, value: "v12", ok: true},
+		{name: "pattern bad", input: InputSpec{Name: "v", Type: "string", Pattern: `^v\d+$`}, value: "12", ok: false},
+		{name: "min", input: InputSpec{Name: "n", Type: "int", Min: &min, Max: &max}, value: 0, ok: false},
+		{name: "max", input: InputSpec{Name: "n", Type: "float", Min: &min, Max: &max}, value: 10.5, ok: false},
+		{name: "range ok", input: InputSpec{Name: "n", Type: "int", Min: &min, Max: &max}, value: 5, ok: true},
+		{name: "min items", input: InputSpec{Name: "m", Type: "multienum", MinItems: &minItems}, value: []string{}, ok: false},
+		{name: "max items", input: InputSpec{Name: "m", Type: "multienum", MaxItems: &maxItems}, value: []string{"a", "b", "c"}, ok: false},
+		{name: "must exist", input: InputSpec{Name: "p", Type: "path", MustExist: true}, value: filepath.Join(base, "missing"), ok: false},
+		{name: "file ok", input: InputSpec{Name: "p", Type: "path", File: true}, value: file, ok: true},
+		{name: "dir bad", input: InputSpec{Name: "p", Type: "path", Dir: true}, value: file, ok: false},
+		{name: "dir ok", input: InputSpec{Name: "p", Type: "path", Dir: true}, value: base, ok: true},
+	}
+	for _, tt := range tests {
+		err := ValidateInputValue(tt.input, tt.value)
+		if tt.ok && err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if !tt.ok && err == nil {
+			t.Fatalf("%s: expected error", tt.name)
+		}
+	}
+}
+
+func TestParseManifestRejectsInvalidPattern(t *testing.T) {
+	data := []byte(`{"schemaVersion":1,"plugin":{"id":"p"},"tasks":[{"name":"t","inputs":[{"name":"v","type":"string","pattern":"("}]}]}`)
+	if _, err := ParseManifest(data); err == nil {
+		t.Fatal("expected error for invalid pattern")
+	}
+}
+
+func TestResolveArgsValidatesDefaults(t *testing.T) {
+	min := 1.0
+	inputs := []InputSpec{{Name: "n", Type: "int", Default: 0.0, Min: &min}}
+	if _, err := ResolveArgs(inputs, nil, "", ""); err == nil {
+		t.Fatal("expected default to be validated")
+	}
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	WorkdirRepoRoot  = "repoRoot"
+	WorkdirCwd       = "cwd"
+	WorkdirPluginDir = "pluginDir"
+)
+
+// TaskWorkdir resolves the directory a task runs in, for both exec modes:
+// the task's workdir, else the plugin's. Without either, direct tasks run in
+// the root of the level their spec comes from (the repo root for nearest
+// and global specs, the cwd outside a repo) and protocol plugins inherit the
+// process cwd. Relative workdirs resolve against the same level root. pluginDir is the spec file's directory for spec tasks and the
+// executable's directory otherwise. An empty result inherits the process cwd.
+func TaskWorkdir(task TaskRecord, repoRoot, cwd string) (string, error) {
+	workdir := task.Task.Workdir
+	if workdir == "" {
+		workdir = task.PluginWorkdir
+	}
+	if workdir == "" && !task.DirectExec {
+		return "", nil
+	}
+	levelRoot := repoRoot
+	if task.LevelRoot != "" {
+		levelRoot = task.LevelRoot
+	}
+	var dir string
+	switch workdir {
+	case "":
+		dir = levelRoot
+		if dir == "" {
+			dir = cwd
+		}
+	case WorkdirRepoRoot:
+		dir = repoRoot
+		if dir == "" {
+			dir = cwd
+		}
+	case WorkdirCwd:
+		dir = cwd
+	case WorkdirPluginDir:
+		if task.SpecPath != "" {
+			dir = filepath.Dir(task.SpecPath)
+		} else {
+			dir = filepath.Dir(task.PluginPath)
+		}
+	default:
+		if levelRoot == "" {
+			return "", fmt.Errorf("task %s: workdir %q requires a repo", TaskID(task.PluginID, task.Task.Name), workdir)
+		}
+		dir = filepath.Join(levelRoot, filepath.FromSlash(workdir))
+	}
+	if dir == "" {
+		return "", nil
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", fmt.Errorf("task %s: workdir: %w", TaskID(task.PluginID, task.Task.Name), err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("task %s: workdir %s is not a directory", TaskID(task.PluginID, task.Task.Name), dir)
+	}
+	return dir, nil
+}
+
+// validateWorkdir accepts the named workdirs and relative paths that stay
+// inside the repo.
+func validateWorkdir(workdir string) error {
+	switch workdir {
+	case "", WorkdirRepoRoot, WorkdirCwd, WorkdirPluginDir:
+		return nil
+	}
+	if filepath.IsAbs(workdir) || strings.HasPrefix(workdir, "~") {
+		return fmt.Errorf("workdir %q must be %s, %s, %s or a repo-relative path", workdir, WorkdirRepoRoot, WorkdirCwd, WorkdirPluginDir)
+	}
+	clean := filepath.Clean(filepath.FromSlash(workdir))
+	if clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("workdir %q escapes the repo", workdir)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTaskWorkdir(t *testing.T) {
+	base := t.TempDir()
+	repo := filepath.Join(base, "repo")
+	cwd := filepath.Join(repo, "sub")
+	web := filepath.Join(repo, "web")
+	specs := filepath.Join(base, "specs")
+	for _, dir := range []string{cwd, web, specs} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cases := []struct {
+		task TaskRecord
+		want string
+	}{
+		{TaskRecord{DirectExec: true}, repo},
+		{TaskRecord{}, ""},
+		{TaskRecord{PluginWorkdir: WorkdirCwd}, cwd},
+		{TaskRecord{PluginWorkdir: WorkdirCwd, Task: TaskSpec{Workdir: "web"}}, web},
+		{TaskRecord{Task: TaskSpec{Workdir: WorkdirPluginDir}, PluginPath: "/bin/ls", SpecPath: filepath.Join(specs, "ls.json")}, specs},
+	}
+	for _, tc := range cases {
+		got, err := TaskWorkdir(tc.task, repo, cwd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Fatalf("expected %s, got %s", tc.want, got)
+		}
+	}
+	pkg := filepath.Join(repo, "pkg")
+	if err := os.MkdirAll(filepath.Join(repo, "web", "app"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(pkg, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	outer := []struct {
+		task TaskRecord
+		want string
+	}{
+		{TaskRecord{DirectExec: true, LevelRoot: repo}, repo},
+		{TaskRecord{DirectExec: true, LevelRoot: repo, Task: TaskSpec{Workdir: "web/app"}}, filepath.Join(repo, "web", "app")},
+		{TaskRecord{DirectExec: true, LevelRoot: repo, Task: TaskSpec{Workdir: WorkdirRepoRoot}}, pkg},
+	}
+	for _, tc := range outer {
+		got, err := TaskWorkdir(tc.task, pkg, cwd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Fatalf("expected %s for outer-level task, got %s", tc.want, got)
+		}
+	}
+	if _, err := TaskWorkdir(TaskRecord{Task: TaskSpec{Workdir: "missing"}}, repo, cwd); err == nil {
+		t.Fatal("expected error for missing workdir")
+	}
+}
+
+func TestValidateWorkdir(t *testing.T) {
+	for _, workdir := range []string{"", "repoRoot", "cwd", "pluginDir", "web/app"} {
+		if err := validateWorkdir(workdir); err != nil {
+			t.Fatalf("expected %q to be valid: %v", workdir, err)
+		}
+	}
+	for _, workdir := range []string{"/tmp", "../other", "~/x"} {
+		if err := validateWorkdir(workdir); err == nil {
+			t.Fatalf("expected %q to be rejected", workdir)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// WorkspaceRepo is a member repo of the workspace. Name prefixes the ids of
+// its tasks.
+type WorkspaceRepo struct {
+	Name string
+	Root string
+}
+
+// WorkspaceRepos resolves the workspace entries of the config into member
+// repos sorted by name. Entries are repo roots or globs; "~" is expanded
+// and relative entries are resolved against repoRoot. Only the global
+// config and trusted repos may list members, so a cloned repo cannot pull
+// other directories into the task list. The current repo is always a
+// member. Without workspace entries it returns nothing.
+func WorkspaceRepos(repoRoot string) ([]WorkspaceRepo, Diagnostics, error) {
+	config, err := loadTrustedConfig(repoRoot)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(config.Workspace) == 0 {
+		return nil, nil, nil
+	}
+	var diagnostics Diagnostics
+	var roots []string
+	if repoRoot != "" {
+		roots = append(roots, repoRoot)
+	}
+	for _, entry := range config.Workspace {
+		pattern := expandHome(entry)
+		if !filepath.IsAbs(pattern) {
+			if repoRoot == "" {
+				diagnostics.Warn("", fmt.Sprintf("workspace entry %s is relative and there is no repo to resolve it against", entry), nil)
+				continue
+			}
+			pattern = filepath.Join(repoRoot, pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			diagnostics.Warn("", "invalid workspace entry "+entry, err)
+			continue
+		}
+		var dirs []string
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil && info.IsDir() {
+				dirs = append(dirs, match)
+			}
+		}
+		if len(dirs) == 0 {
+			diagnostics.Warn("", fmt.Sprintf("workspace entry %s matches no directory", entry), nil)
+			continue
+		}
+		roots = append(roots, dirs...)
+	}
+	var repos []WorkspaceRepo
+	seenRoots := make(map[string]bool)
+	byName := make(map[string]string)
+	for _, root := range roots {
+		root = filepath.Clean(root)
+		if seenRoots[root] {
+			continue
+		}
+		seenRoots[root] = true
+		name := filepath.Base(root)
+		if other, ok := byName[name]; ok {
+			diagnostics.Warn(root, fmt.Sprintf("workspace repo %s skipped: %s has the same name", root, other), nil)
+			continue
+		}
+		byName[name] = root
+		repos = append(repos, WorkspaceRepo{Name: name, Root: root})
+	}
+	sort.Slice(repos, func(i, j int) bool {
+		return repos[i].Name < repos[j].Name
+	})
+	return repos, diagnostics, nil
+}
@@ -0,0 +1,74 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWorkspaceRepos(t *testing.T) {
+	base := t.TempDir()
+	repo := filepath.Join(base, "repo")
+	home := filepath.Join(base, "home")
+	for _, dir := range []string{
+		filepath.Join(repo, localConfigDirName),
+		filepath.Join(base, "projects", "api"),
+		filepath.Join(base, "projects", "web"),
+		filepath.Join(home, "tools"),
+		filepath.Join(base, "other", "api"),
+	} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(base, "projects", "notes.txt"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	config := `{"workspace": ["../projects/*", "~/tools", "` + repo + `", "` + filepath.Join(base, "other", "api") + `", "../missing/*"]}`
+	if err := os.WriteFile(filepath.Join(repo, localConfigDirName, configFileName), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+	t.Setenv("HOME", home)
+
+	repos, _, err := WorkspaceRepos(repo)
+	if err != nil || repos != nil {
+		t.Fatalf("expected an untrusted repo not to list workspace repos, got %v %v", repos, err)
+	}
+	if _, err := TrustRepo(repo); err != nil {
+		t.Fatal(err)
+	}
+	repos, diagnostics, err := WorkspaceRepos(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []WorkspaceRepo{
+		{Name: "api", Root: filepath.Join(base, "projects", "api")},
+		{Name: "repo", Root: repo},
+		{Name: "tools", Root: filepath.Join(home, "tools")},
+		{Name: "web", Root: filepath.Join(base, "projects", "web")},
+	}
+	if len(repos) != len(want) {
+		t.Fatalf("expected %v, got %v", want, repos)
+	}
+	for i := range want {
+		if repos[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, repos)
+		}
+	}
+	if len(diagnostics) != 2 {
+		t.Fatalf("expected duplicate name and empty glob diagnostics, got %v", diagnostics)
+	}
+}
+
+func TestWorkspaceReposWithoutEntries(t *testing.T) {
+	base := t.TempDir()
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+
+	repos, diagnostics, err := WorkspaceRepos(base)
+	if err != nil || repos != nil || diagnostics != nil {
+		t.Fatalf("expected no workspace, got %v %v %v", repos, diagnostics, err)
+	}
+}
@@ -21,12 +21,12 @@ func (b *BubbleUI) ClearScreen() {
 	fmt.Print("\x1b[2J\x1b[H")
 }
 
-func (b *BubbleUI) SelectTask(tasks []core.TaskRecord, state app.SelectionState) (core.TaskRecord, app.SelectionState, error) {
-	return SelectTask(tasks, state)
+func (b *BubbleUI) SelectTask(tasks []core.TaskRecord, state app.SelectionState, diagnostics core.Diagnostics) (core.TaskRecord, app.SelectionState, error) {
+	return SelectTask(tasks, state, diagnostics)
 }
 
-func (b *BubbleUI) PromptInputs(inputs []core.InputSpec, defaults map[string]any) (map[string]any, error) {
-	return PromptInputsWithDefaults(inputs, defaults)
+func (b *BubbleUI) PromptInputs(inputs []core.InputSpec, defaults map[string]any, ctx app.PromptContext) (map[string]any, error) {
+	return PromptInputsWithContext(inputs, defaults, ctx)
 }
 
 func (b *BubbleUI) RenderRunning(taskID, pluginTitle string) {
@@ -11,7 +11,7 @@ type fakeSelect struct {
 	state app.SelectionState
 }
 
-func (f *fakeSelect) SelectTask(tasks []core.TaskRecord, state app.SelectionState) (core.TaskRecord, app.SelectionState, error) {
+func (f *fakeSelect) SelectTask(tasks []core.TaskRecord, state app.SelectionState, diagnostics core.Diagnostics) (core.TaskRecord, app.SelectionState, error) {
 	f.state = state
 	return core.TaskRecord{}, app.SelectionState{Filter: "keep", Cursor: 2}, nil
 }
@@ -25,7 +25,7 @@ func TestBubbleUISelectTaskPassesState(t *testing.T) {
 	SelectTask = recorder.SelectTask
 
 	state := app.SelectionState{Filter: "q", Cursor: 1}
-	_, next, err := ui.SelectTask([]core.TaskRecord{}, state)
+	_, next, err := ui.SelectTask([]core.TaskRecord{}, state, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -0,0 +1,150 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/ea2809/automate-me/internal/core"
+)
+
+// promptDate picks a date input starting from the default, or today.
+func promptDate(prompt string, input core.InputSpec) (any, error) {
+	initial := time.Now()
+	if input.Default != nil {
+		if def, err := time.Parse(core.DateLayout, fmt.Sprint(input.Default)); err == nil {
+			initial = def
+		}
+	}
+	for {
+		value, err := selectDate(prompt, initial)
+		if err != nil {
+			return nil, err
+		}
+		if value == "" {
+			if input.Required {
+				fmt.Fprintf(os.Stderr, "missing required input: %s\n", input.Name)
+				continue
+			}
+			return nil, nil
+		}
+		parsed, err := core.ParseInputValue(input, value)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			initial, _ = time.Parse(core.DateLayout, value)
+			continue
+		}
+		return parsed, nil
+	}
+}
+
+type dateModel struct {
+	title    string
+	date     time.Time
+	typed    string
+	err      string
+	cleared  bool
+	canceled bool
+	theme    Theme
+}
+
+func selectDate(prompt string, initial time.Time) (string, error) {
+	model := dateModel{
+		title: prompt,
+		date:  initial,
+		theme: DefaultTheme(),
+	}
+	program := tea.NewProgram(model)
+	result, err := program.Run()
+	if err != nil {
+		return "", err
+	}
+	final := result.(dateModel)
+	if final.canceled {
+		return "", ErrUserCanceled
+	}
+	if final.cleared {
+		return "", nil
+	}
+	return final.date.Format(core.DateLayout), nil
+}
+
+func (m dateModel) Init() tea.Cmd { return nil }
+
+func (m dateModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	m.err = ""
+	switch key.String() {
+	case "ctrl+c", "esc":
+		m.canceled = true
+		return m, tea.Quit
+	case "ctrl+d":
+		m.cleared = true
+		return m, tea.Quit
+	case "enter":
+		if m.typed == "" {
+			return m, tea.Quit
+		}
+		date, err := time.Parse(core.DateLayout, m.typed)
+		if err != nil {
+			m.err = "expected " + core.DateLayout
+			return m, nil
+		}
+		m.date = date
+		m.typed = ""
+		return m, tea.Quit
+	case "left":
+		m.date = m.date.AddDate(0, 0, -1)
+	case "right":
+		m.date = m.date.AddDate(0, 0, 1)
+	case "up":
+		m.date = m.date.AddDate(0, 0, -7)
+	case "down":
+		m.date = m.date.AddDate(0, 0, 7)
+	case "pgup":
+		m.date = m.date.AddDate(0, -1, 0)
+	case "pgdown":
+		m.date = m.date.AddDate(0, 1, 0)
+	case "backspace", "ctrl+h":
+		if len(m.typed) > 0 {
+			m.typed = m.typed[:len(m.typed)-1]
+		}
+	default:
+		if key.Type == tea.KeyRunes {
+			for _, r := range key.Runes {
+				if (r >= '0' && r <= '9') || r == '-' {
+					m.typed += string(r)
+				}
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m dateModel) View() string {
+	var b strings.Builder
+	b.WriteString(m.theme.Title.Render(m.title))
+	b.WriteString("\n\n")
+	if m.typed != "" {
+		b.WriteString(m.typed)
+		b.WriteString(m.theme.Selected.Render(" "))
+	} else {
+		b.WriteString(m.theme.Selected.Render(m.date.Format(core.DateLayout)))
+		b.WriteString(" ")
+		b.WriteString(m.date.Format("Monday"))
+	}
+	if m.err != "" {
+		b.WriteString("\n")
+		b.WriteString(m.theme.Error.Render(m.err))
+	}
+	b.WriteString("\n\n")
+	b.WriteString(m.theme.Footer.Render("←/→: day  ↑/↓: week  PgUp/PgDn: month  type YYYY-MM-DD  Enter: select  Ctrl+D: clear  Esc: cancel"))
+	b.WriteString("\n")
+	return b.String()
+}
@@ -0,0 +1,31 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestDateModelKeys(t *testing.T) {
+	start, _ := time.Parse("2006-01-02", "2024-01-31")
+	var model tea.Model = dateModel{date: start, theme: DefaultTheme()}
+	for _, key := range []tea.KeyMsg{{Type: tea.KeyRight}, {Type: tea.KeyDown}, {Type: tea.KeyPgUp}} {
+		model, _ = model.Update(key)
+	}
+	if got := model.(dateModel).date.Format("2006-01-02"); got != "2024-01-08" {
+		t.Fatalf("expected 2024-01-08, got %s", got)
+	}
+
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("2025-13-01")})
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if model.(dateModel).err == "" {
+		t.Fatal("expected invalid typed date to be refused")
+	}
+	typed := model.(dateModel)
+	typed.typed = "2025-02-03"
+	model, _ = typed.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if got := model.(dateModel).date.Format("2006-01-02"); got != "2025-02-03" {
+		t.Fatalf("expected typed date, got %s", got)
+	}
+}
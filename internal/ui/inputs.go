@@ -4,7 +4,7 @@ import (
 	"bufio"
 	"fmt"
 	"os"
-	"strconv"
+	"sort"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -20,16 +20,52 @@ func PromptInputs(inputs []core.InputSpec) (map[string]any, error) {
 }
 
 func PromptInputsWithDefaults(inputs []core.InputSpec, defaults map[string]any) (map[string]any, error) {
+	return PromptInputsWithContext(inputs, defaults, app.PromptContext{})
+}
+
+// PromptInputsWithContext prompts inputs in order, skipping inputs whose when
+// condition does not hold or that ctx presets, and resolving choicesFrom
+// inputs through ctx.Choices with the values collected so far.
+func PromptInputsWithContext(inputs []core.InputSpec, defaults map[string]any, ctx app.PromptContext) (map[string]any, error) {
 	values := make(map[string]any)
 	reader := bufio.NewReader(os.Stdin)
 
 	for _, input := range inputs {
-		if defaults != nil {
+		if !core.InputActive(input, values) {
+			continue
+		}
+		if preset, ok := ctx.Preset[input.Name]; ok {
+			prompt := input.Prompt
+			if prompt == "" {
+				prompt = input.Name
+			}
+			fmt.Fprintf(os.Stderr, "%s: %s (preset by overrides)\n", prompt, formatValue(preset))
+			values[input.Name] = preset
+			continue
+		}
+		if input.Secret {
+			secret, found, err := core.ResolveSecret(input, ctx.RepoRoot)
+			if err != nil {
+				return nil, err
+			}
+			if found {
+				values[input.Name] = secret
+				continue
+			}
+			input.Default = nil
+		} else if defaults != nil {
 			if override, ok := defaults[input.Name]; ok {
 				input.Default = override
 			}
 		}
-		value, err := promptOneInput(reader, input)
+		if input.ChoicesFrom != "" && ctx.Choices != nil {
+			choices, err := ctx.Choices(input, values)
+			if err != nil {
+				return nil, err
+			}
+			input.Choices = choices
+		}
+		value, err := promptOneInput(reader, input, ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -39,7 +75,7 @@ func PromptInputsWithDefaults(inputs []core.InputSpec, defaults map[string]any)
 	return values, nil
 }
 
-func promptOneInput(reader *bufio.Reader, input core.InputSpec) (any, error) {
+func promptOneInput(reader *bufio.Reader, input core.InputSpec, ctx app.PromptContext) (any, error) {
 	prompt := input.Prompt
 	if prompt == "" {
 		prompt = input.Name
@@ -58,18 +94,38 @@ func promptOneInput(reader *bufio.Reader, input core.InputSpec) (any, error) {
 		return nil, fmt.Errorf("no choices available for %s", input.Name)
 	}
 	if input.Type == "multienum" && len(input.Choices) > 0 {
-		choices, err := selectMultiEnum(prompt, input.Choices, input.Default)
-		if err != nil {
-			return nil, err
-		}
-		if input.Required && len(choices) == 0 {
-			return nil, fmt.Errorf("missing required input: %s", input.Name)
+		def := input.Default
+		for {
+			choices, err := selectMultiEnum(prompt, input.Choices, def)
+			if err != nil {
+				return nil, err
+			}
+			if input.Required && len(choices) == 0 {
+				return nil, fmt.Errorf("missing required input: %s", input.Name)
+			}
+			if err := core.ValidateInputValue(input, choices); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				def = choices
+				continue
+			}
+			return choices, nil
 		}
-		return choices, nil
 	}
 	if input.Type == "multienum" && len(input.Choices) == 0 && input.Required {
 		return nil, fmt.Errorf("no choices available for %s", input.Name)
 	}
+	if input.Type == "text" {
+		return promptText(prompt, input)
+	}
+	if input.Type == "path" && !input.Secret {
+		return promptPath(prompt, input, ctx)
+	}
+	if (input.Type == "list" || input.Type == "kv") && !input.Secret {
+		return promptLines(prompt, input)
+	}
+	if input.Type == "date" && !input.Secret {
+		return promptDate(prompt, input)
+	}
 	for {
 		fmt.Print(formatPrompt(prompt, input))
 		line, err := readInput(reader, input.Secret)
@@ -78,14 +134,82 @@ func promptOneInput(reader *bufio.Reader, input core.InputSpec) (any, error) {
 		}
 		line = strings.TrimSpace(line)
 		if line == "" && input.Default != nil {
-			return input.Default, nil
+			value, err := core.NormalizeValue(input, input.Default)
+			if err == nil {
+				err = core.ValidateInputValue(input, value)
+			}
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				continue
+			}
+			return value, nil
 		}
 		if line == "" && !input.Required {
 			return nil, nil
 		}
-		value, err := parseInputValue(input, line)
+		value, err := core.ParseInputValue(input, line)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			continue
+		}
+		return value, nil
+	}
+}
+
+func promptText(prompt string, input core.InputSpec) (any, error) {
+	initial := ""
+	if input.Default != nil {
+		initial = fmt.Sprint(input.Default)
+	}
+	for {
+		text, err := editText(prompt, initial)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(text) == "" {
+			if input.Required {
+				fmt.Fprintf(os.Stderr, "missing required input: %s\n", input.Name)
+				continue
+			}
+			return nil, nil
+		}
+		value, err := core.ParseInputValue(input, text)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			initial = text
+			continue
+		}
+		return value, nil
+	}
+}
+
+// promptLines edits a list or kv input one entry per line in the text
+// editor, starting from the default.
+func promptLines(prompt string, input core.InputSpec) (any, error) {
+	initial := ""
+	if input.Default != nil {
+		if def, err := core.NormalizeValue(input, input.Default); err == nil {
+			initial = strings.Join(valueParts(def), "\n")
+		}
+	}
+	if input.Type == "kv" {
+		prompt += " (one key=value per line)"
+	} else {
+		prompt += " (one entry per line)"
+	}
+	for {
+		text, err := editText(prompt, initial)
+		if err != nil {
+			return nil, err
+		}
+		value, err := core.ParseInputLines(input, text)
 		if err != nil {
 			fmt.Fprintln(os.Stderr, err)
+			initial = text
+			continue
+		}
+		if value == nil && input.Required {
+			fmt.Fprintf(os.Stderr, "missing required input: %s\n", input.Name)
 			continue
 		}
 		return value, nil
@@ -114,8 +238,11 @@ func formatPrompt(prompt string, input core.InputSpec) string {
 	if len(input.Choices) > 0 {
 		extras = append(extras, "choices: "+strings.Join(input.Choices, ","))
 	}
+	if hint := typeHint(input.Type); hint != "" {
+		extras = append(extras, hint)
+	}
 	if input.Default != nil {
-		extras = append(extras, fmt.Sprintf("default: %v", input.Default))
+		extras = append(extras, "default: "+formatValue(input.Default))
 	}
 	if len(extras) > 0 {
 		return fmt.Sprintf("%s (%s): ", prompt, strings.Join(extras, "; "))
@@ -123,76 +250,56 @@ func formatPrompt(prompt string, input core.InputSpec) string {
 	return fmt.Sprintf("%s: ", prompt)
 }
 
-func parseInputValue(input core.InputSpec, raw string) (any, error) {
-	switch input.Type {
-	case "string", "path":
-		return raw, nil
-	case "int":
-		value, err := strconv.Atoi(raw)
-		if err != nil {
-			return nil, fmt.Errorf("invalid int for %s", input.Name)
-		}
-		return value, nil
-	case "float":
-		value, err := strconv.ParseFloat(raw, 64)
-		if err != nil {
-			return nil, fmt.Errorf("invalid float for %s", input.Name)
-		}
-		return value, nil
-	case "bool":
-		switch strings.ToLower(raw) {
-		case "true", "t", "yes", "y", "1":
-			return true, nil
-		case "false", "f", "no", "n", "0":
-			return false, nil
-		default:
-			return nil, fmt.Errorf("invalid bool for %s", input.Name)
-		}
-	case "enum":
-		return parseEnum(input, raw)
-	case "multienum":
-		parts := splitCSV(raw)
-		for _, part := range parts {
-			if !contains(input.Choices, part) {
-				return nil, fmt.Errorf("invalid choice %q for %s", part, input.Name)
-			}
-		}
-		return parts, nil
-	default:
-		return nil, fmt.Errorf("unsupported input type %q for %s", input.Type, input.Name)
-	}
+func typeHint(inputType string) string {
+	switch inputType {
+	case "duration":
+		return "e.g. 1h30m"
+	case "date":
+		return core.DateLayout
+	case "list":
+		return "comma separated"
+	case "kv":
+		return "key=value,..."
+	}
+	return ""
 }
 
-func parseEnum(input core.InputSpec, raw string) (string, error) {
-	if len(input.Choices) == 0 {
-		return raw, nil
-	}
-	if !contains(input.Choices, raw) {
-		return "", fmt.Errorf("invalid choice %q for %s", raw, input.Name)
+func formatValue(value any) string {
+	switch value.(type) {
+	case []string, []any, map[string]string, map[string]any:
+		return strings.Join(valueParts(value), ",")
 	}
-	return raw, nil
+	return fmt.Sprint(value)
 }
 
-func splitCSV(raw string) []string {
-	parts := strings.Split(raw, ",")
-	var out []string
-	for _, part := range parts {
-		p := strings.TrimSpace(part)
-		if p == "" {
-			continue
+// valueParts returns the entries of a list value, or the sorted key=value
+// entries of a kv value.
+func valueParts(value any) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = fmt.Sprint(item)
 		}
-		out = append(out, p)
-	}
-	return out
-}
-
-func contains(values []string, target string) bool {
-	for _, value := range values {
-		if value == target {
-			return true
+		return parts
+	case map[string]string:
+		parts := make([]string, 0, len(v))
+		for key, item := range v {
+			parts = append(parts, key+"="+item)
+		}
+		sort.Strings(parts)
+		return parts
+	case map[string]any:
+		parts := make([]string, 0, len(v))
+		for key, item := range v {
+			parts = append(parts, fmt.Sprintf("%s=%v", key, item))
 		}
+		sort.Strings(parts)
+		return parts
 	}
-	return false
+	return []string{fmt.Sprint(value)}
 }
 
 type enumModel struct {
@@ -0,0 +1,23 @@
+package ui
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/ea2809/automate-me/internal/app"
+	"github.com/ea2809/automate-me/internal/core"
+)
+
+func TestPromptOneInputValidatesDefault(t *testing.T) {
+	min := 1.0
+	input := core.InputSpec{Name: "n", Type: "int", Default: 0.0, Min: &min}
+	reader := bufio.NewReader(strings.NewReader("\n5\n"))
+	value, err := promptOneInput(reader, input, app.PromptContext{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != 5 {
+		t.Fatalf("expected the invalid default to be refused and 5 read, got %#v", value)
+	}
+}
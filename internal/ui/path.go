@@ -0,0 +1,281 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ea2809/automate-me/internal/app"
+	"github.com/ea2809/automate-me/internal/core"
+)
+
+func promptPath(prompt string, input core.InputSpec, ctx app.PromptContext) (any, error) {
+	cwd := ctx.Cwd
+	if cwd == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		cwd = wd
+	}
+	root := core.PathBase(input, ctx.RepoRoot, cwd)
+	initial := ""
+	if input.Default != nil {
+		initial = fmt.Sprint(input.Default)
+	}
+	for {
+		raw, err := selectPath(prompt, root, initial, input)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(raw) == "" {
+			if input.Required {
+				fmt.Fprintf(os.Stderr, "missing required input: %s\n", input.Name)
+				continue
+			}
+			return nil, nil
+		}
+		value, err := core.ResolvePathInput(input, raw, ctx.RepoRoot, cwd)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			initial = raw
+			continue
+		}
+		return value, nil
+	}
+}
+
+type pathEntry struct {
+	name string
+	dir  bool
+}
+
+type pathModel struct {
+	title    string
+	root     string
+	input    core.InputSpec
+	value    string
+	entries  []pathEntry
+	cursor   int
+	canceled bool
+	height   int
+	theme    Theme
+}
+
+func selectPath(prompt, root, initial string, input core.InputSpec) (string, error) {
+	model := pathModel{
+		title: prompt,
+		root:  root,
+		input: input,
+		value: initial,
+		theme: DefaultTheme(),
+	}
+	model.refresh()
+	program := tea.NewProgram(model)
+	result, err := program.Run()
+	if err != nil {
+		return "", err
+	}
+	final := result.(pathModel)
+	if final.canceled {
+		return "", ErrUserCanceled
+	}
+	return final.value, nil
+}
+
+func (m pathModel) Init() tea.Cmd { return nil }
+
+func (m pathModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			m.canceled = true
+			return m, tea.Quit
+		case "up":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down":
+			if m.cursor < len(m.entries)-1 {
+				m.cursor++
+			}
+		case "tab":
+			m.value = completePath(m.value, m.entries)
+			m.refresh()
+		case "ctrl+d":
+			return m, tea.Quit
+		case "enter":
+			if len(m.entries) == 0 {
+				return m, tea.Quit
+			}
+			dir, _ := splitPathValue(m.value)
+			entry := m.entries[m.cursor]
+			if !entry.dir {
+				m.value = dir + entry.name
+				return m, tea.Quit
+			}
+			m.value = dir + entry.name + "/"
+			m.refresh()
+		case "backspace", "ctrl+h":
+			if len(m.value) > 0 {
+				runes := []rune(m.value)
+				m.value = string(runes[:len(runes)-1])
+				m.refresh()
+			}
+		default:
+			if msg.Type == tea.KeySpace {
+				m.value += " "
+				m.refresh()
+			}
+			if msg.Type == tea.KeyRunes && len(msg.Runes) > 0 {
+				m.value += string(msg.Runes)
+				m.refresh()
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m pathModel) View() string {
+	var b strings.Builder
+	b.WriteString(m.theme.Title.Render(m.title))
+	b.WriteString("\n")
+	b.WriteString(m.theme.Filter.Render("Path: " + m.value))
+	b.WriteString("\n")
+	b.WriteString(m.theme.Dim.Render("In: " + m.listDir()))
+	b.WriteString("\n\n")
+	start, end := visibleRangeInput(len(m.entries), m.cursor, m.maxRows())
+	if start > 0 || end < len(m.entries) {
+		b.WriteString(m.theme.Dim.Render(fmt.Sprintf("Showing %d-%d of %d", start+1, end, len(m.entries))))
+		b.WriteString("\n")
+	}
+	for i := start; i < end; i++ {
+		name := m.entries[i].name
+		if m.entries[i].dir {
+			name += "/"
+		}
+		if i == m.cursor {
+			b.WriteString(m.theme.Selected.Render(" > " + name))
+			b.WriteString("\n")
+			continue
+		}
+		b.WriteString("   " + name)
+		b.WriteString("\n")
+	}
+	if len(m.entries) == 0 {
+		b.WriteString(m.theme.Dim.Render("No matches. Enter accepts the typed path."))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(m.theme.Footer.Render("Enter: open/select  Tab: complete  Ctrl+D: accept typed path  Esc: cancel  ↑/↓: move"))
+	b.WriteString("\n")
+	return b.String()
+}
+
+func (m *pathModel) refresh() {
+	m.entries = listPathEntries(m.listDir(), m.value, m.input)
+	if m.cursor >= len(m.entries) {
+		m.cursor = len(m.entries) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+}
+
+func (m pathModel) listDir() string {
+	dir, _ := splitPathValue(m.value)
+	if filepath.IsAbs(dir) {
+		return filepath.Clean(dir)
+	}
+	return filepath.Join(m.root, dir)
+}
+
+func (m pathModel) maxRows() int {
+	if m.height > 0 {
+		rows := m.height - 7
+		if rows > 0 {
+			return rows
+		}
+	}
+	return 10
+}
+
+// splitPathValue splits a typed path into its directory part (kept with the
+// trailing separator) and the segment being typed. Both "/" and the OS
+// separator end the directory part.
+func splitPathValue(value string) (string, string) {
+	idx := strings.LastIndexFunc(value, func(r rune) bool { return r == '/' || os.IsPathSeparator(uint8(r)) })
+	if idx < 0 {
+		return "", value
+	}
+	return value[:idx+1], value[idx+1:]
+}
+
+// listPathEntries lists dir entries starting with the typed segment, with
+// directories first and files limited by the input's filters.
+func listPathEntries(dir, value string, input core.InputSpec) []pathEntry {
+	_, prefix := splitPathValue(value)
+	items, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var entries []pathEntry
+	for _, item := range items {
+		name := item.Name()
+		if strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".") {
+			continue
+		}
+		if !strings.HasPrefix(strings.ToLower(name), strings.ToLower(prefix)) {
+			continue
+		}
+		isDir := item.IsDir()
+		if !isDir {
+			if info, err := os.Stat(filepath.Join(dir, name)); err == nil && info.IsDir() {
+				isDir = true
+			}
+		}
+		if !isDir && (input.Dir || !core.MatchesPathFilter(input, name)) {
+			continue
+		}
+		entries = append(entries, pathEntry{name: name, dir: isDir})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].dir != entries[j].dir {
+			return entries[i].dir
+		}
+		return entries[i].name < entries[j].name
+	})
+	return entries
+}
+
+// completePath extends the typed segment to the single match, or to the
+// longest prefix shared by all matches.
+func completePath(value string, entries []pathEntry) string {
+	if len(entries) == 0 {
+		return value
+	}
+	dir, prefix := splitPathValue(value)
+	if len(entries) == 1 {
+		completed := dir + entries[0].name
+		if entries[0].dir {
+			completed += "/"
+		}
+		return completed
+	}
+	common := entries[0].name
+	for _, entry := range entries[1:] {
+		for !strings.HasPrefix(entry.name, common) {
+			common = common[:len(common)-1]
+		}
+	}
+	if len(common) <= len(prefix) {
+		return value
+	}
+	return dir + common
+}
@@ -0,0 +1,55 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ea2809/automate-me/internal/core"
+)
+
+func TestListPathEntriesAndComplete(t *testing.T) {
+	base := t.TempDir()
+	for _, dir := range []string{"src", "scripts"} {
+		if err := os.Mkdir(filepath.Join(base, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{"setup.json", "setup.txt", ".hidden.json"} {
+		if err := os.WriteFile(filepath.Join(base, file), []byte("{}"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	input := core.InputSpec{Name: "p", Type: "path", Extensions: []string{"json"}}
+
+	entries := listPathEntries(base, "s", input)
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %+v", entries)
+	}
+	if !entries[0].dir || !entries[1].dir || entries[2].name != "setup.json" {
+		t.Fatalf("expected dirs first then filtered files, got %+v", entries)
+	}
+
+	if got := completePath("s", entries); got != "s" {
+		t.Fatalf("expected no completion for ambiguous prefix, got %s", got)
+	}
+	srcEntries := listPathEntries(base, "sr", input)
+	if got := completePath("sr", srcEntries); got != "src/" {
+		t.Fatalf("expected src/, got %s", got)
+	}
+	scEntries := listPathEntries(base, "sc", input)
+	if got := completePath("sc", scEntries); got != "scripts/" {
+		t.Fatalf("expected scripts/, got %s", got)
+	}
+}
+
+func TestSplitPathValueUsesSeparators(t *testing.T) {
+	value := filepath.Join("src", "app", "ma")
+	dir, prefix := splitPathValue(value)
+	if dir != filepath.Join("src", "app")+string(filepath.Separator) || prefix != "ma" {
+		t.Fatalf("unexpected split %q %q", dir, prefix)
+	}
+	if dir, prefix := splitPathValue("src/ma"); dir != "src/" || prefix != "ma" {
+		t.Fatalf("unexpected split %q %q", dir, prefix)
+	}
+}
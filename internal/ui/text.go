@@ -0,0 +1,124 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// editText returns multi-line text, using $VISUAL/$EDITOR when set and an
+// inline textarea otherwise.
+func editText(prompt, initial string) (string, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor != "" {
+		return editTextInEditor(editor, initial)
+	}
+	return editTextInline(prompt, initial)
+}
+
+func editTextInEditor(editor, initial string) (string, error) {
+	file, err := os.CreateTemp("", "automate-me-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("create temp file: %w", err)
+	}
+	path := file.Name()
+	defer os.Remove(path)
+	if _, err := file.WriteString(initial); err != nil {
+		file.Close()
+		return "", fmt.Errorf("write temp file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("write temp file: %w", err)
+	}
+	parts := strings.Fields(editor)
+	cmd := exec.Command(parts[0], append(parts[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("run editor: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read temp file: %w", err)
+	}
+	return string(data), nil
+}
+
+type textModel struct {
+	title    string
+	value    []rune
+	done     bool
+	canceled bool
+	theme    Theme
+}
+
+func editTextInline(prompt, initial string) (string, error) {
+	model := textModel{
+		title: prompt,
+		value: []rune(initial),
+		theme: DefaultTheme(),
+	}
+	program := tea.NewProgram(model)
+	result, err := program.Run()
+	if err != nil {
+		return "", err
+	}
+	final := result.(textModel)
+	if final.canceled {
+		return "", ErrUserCanceled
+	}
+	return string(final.value), nil
+}
+
+func (m textModel) Init() tea.Cmd { return nil }
+
+func (m textModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			m.canceled = true
+			return m, tea.Quit
+		case "ctrl+d":
+			m.done = true
+			return m, tea.Quit
+		case "enter":
+			m.value = append(m.value, '\n')
+		case "tab":
+			m.value = append(m.value, '\t')
+		case "backspace", "ctrl+h":
+			if len(m.value) > 0 {
+				m.value = m.value[:len(m.value)-1]
+			}
+		default:
+			if msg.Type == tea.KeySpace {
+				m.value = append(m.value, ' ')
+			}
+			if msg.Type == tea.KeyRunes && len(msg.Runes) > 0 {
+				m.value = append(m.value, msg.Runes...)
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m textModel) View() string {
+	var b strings.Builder
+	b.WriteString(m.theme.Title.Render(m.title))
+	b.WriteString("\n\n")
+	b.WriteString(string(m.value))
+	if !m.done {
+		b.WriteString(m.theme.Selected.Render(" "))
+	}
+	b.WriteString("\n\n")
+	b.WriteString(m.theme.Footer.Render("Enter: newline  Ctrl+D: submit  Esc: cancel"))
+	b.WriteString("\n")
+	return b.String()
+}
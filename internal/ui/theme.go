@@ -15,6 +15,8 @@ type Theme struct {
 	Footer   lipgloss.Style
 	Running  lipgloss.Style
 	Loading  lipgloss.Style
+	Warning  lipgloss.Style
+	Error    lipgloss.Style
 }
 
 func DefaultTheme() Theme {
@@ -24,6 +26,8 @@ func DefaultTheme() Theme {
 	text := colorEnv("AUTOMATE_ME_THEME_TEXT", "15")
 	muted := colorEnv("AUTOMATE_ME_THEME_MUTED", "243")
 	muted2 := colorEnv("AUTOMATE_ME_THEME_MUTED_2", "240")
+	warning := colorEnv("AUTOMATE_ME_THEME_WARNING", "214")
+	errorColor := colorEnv("AUTOMATE_ME_THEME_ERROR", "203")
 
 	return Theme{
 		Title:    lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(accentLight)),
@@ -34,6 +38,8 @@ func DefaultTheme() Theme {
 		Footer:   lipgloss.NewStyle().Foreground(lipgloss.Color(muted2)),
 		Running:  lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(accentLight)),
 		Loading:  lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color(accent)),
+		Warning:  lipgloss.NewStyle().Foreground(lipgloss.Color(warning)),
+		Error:    lipgloss.NewStyle().Foreground(lipgloss.Color(errorColor)),
 	}
 }
 
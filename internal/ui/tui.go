@@ -5,27 +5,38 @@ import (
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/ea2809/automate-me/internal/app"
 	"github.com/ea2809/automate-me/internal/core"
 )
 
+// bannerLimit is the number of diagnostics listed in the banner above the
+// tasks; the details panel lists them all.
+const bannerLimit = 3
+
 type taskModel struct {
-	tasks   []core.TaskRecord
-	filter  string
-	cursor  int
-	width   int
-	height  int
-	choice  *core.TaskRecord
-	theme   Theme
-	refresh bool
+	tasks       []core.TaskRecord
+	diagnostics core.Diagnostics
+	dismissed   bool
+	details     bool
+	inspect     bool
+	filter      string
+	cursor      int
+	width       int
+	height      int
+	choice      *core.TaskRecord
+	theme       Theme
+	refresh     bool
 }
 
-var SelectTask = func(tasks []core.TaskRecord, state app.SelectionState) (core.TaskRecord, app.SelectionState, error) {
+var SelectTask = func(tasks []core.TaskRecord, state app.SelectionState, diagnostics core.Diagnostics) (core.TaskRecord, app.SelectionState, error) {
 	model := taskModel{
-		tasks:  tasks,
-		filter: state.Filter,
-		cursor: state.Cursor,
-		theme:  DefaultTheme(),
+		tasks:       tasks,
+		diagnostics: diagnostics,
+		dismissed:   state.DiagnosticsDismissed,
+		filter:      state.Filter,
+		cursor:      state.Cursor,
+		theme:       DefaultTheme(),
 	}
 	program := tea.NewProgram(model)
 	result, err := program.Run()
@@ -33,13 +44,14 @@ var SelectTask = func(tasks []core.TaskRecord, state app.SelectionState) (core.T
 		return core.TaskRecord{}, state, err
 	}
 	finalModel := result.(taskModel)
+	nextState := app.SelectionState{Filter: finalModel.filter, Cursor: finalModel.cursor, DiagnosticsDismissed: finalModel.dismissed}
 	if finalModel.refresh {
-		return core.TaskRecord{}, app.SelectionState{Filter: finalModel.filter, Cursor: finalModel.cursor}, app.ErrRefresh
+		return core.TaskRecord{}, nextState, app.ErrRefresh
 	}
 	if finalModel.choice == nil {
 		return core.TaskRecord{}, state, ErrUserCanceled
 	}
-	return *finalModel.choice, app.SelectionState{Filter: finalModel.filter, Cursor: finalModel.cursor}, nil
+	return *finalModel.choice, nextState, nil
 }
 
 func (m taskModel) Init() tea.Cmd {
@@ -52,9 +64,40 @@ func (m taskModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 	case tea.KeyMsg:
+		if m.inspect {
+			switch msg.String() {
+			case "ctrl+c":
+				return m, tea.Quit
+			case "esc", "left", "q":
+				m.inspect = false
+			}
+			return m, nil
+		}
+		if m.details {
+			switch msg.String() {
+			case "ctrl+c":
+				return m, tea.Quit
+			case "esc", "tab", "q":
+				m.details = false
+			case "ctrl+d":
+				m.details = false
+				m.dismissed = true
+			}
+			return m, nil
+		}
 		switch msg.String() {
 		case "ctrl+c", "esc":
 			return m, tea.Quit
+		case "tab":
+			if len(m.diagnostics) > 0 {
+				m.details = true
+			}
+		case "right":
+			if len(m.filtered()) > 0 {
+				m.inspect = true
+			}
+		case "ctrl+d":
+			m.dismissed = true
 		case "up", "k":
 			if m.cursor > 0 {
 				m.cursor--
@@ -93,6 +136,18 @@ func (m taskModel) View() string {
 	var b strings.Builder
 	b.WriteString(m.theme.Title.Render("Automate-Me"))
 	b.WriteString("\n")
+	if m.details {
+		m.writeDetails(&b)
+		return b.String()
+	}
+	if m.inspect {
+		m.writeTaskInfo(&b, m.filtered()[m.cursor])
+		return b.String()
+	}
+	for _, line := range m.bannerLines() {
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
 	b.WriteString(m.theme.Filter.Render(fmt.Sprintf("Filter: %s", m.filter)))
 	b.WriteString("\n\n")
 
@@ -120,11 +175,103 @@ func (m taskModel) View() string {
 	}
 
 	b.WriteString("\n")
-	b.WriteString(m.theme.Footer.Render("Enter: run  Esc: cancel  R: refresh  ↑/↓: move  Type: filter"))
+	footer := "Enter: run  Esc: cancel  R: refresh  ↑/↓: move  →: details  Type: filter"
+	if len(m.diagnostics) > 0 {
+		footer += "  Tab: diagnostics"
+	}
+	b.WriteString(m.theme.Footer.Render(footer))
 	b.WriteString("\n")
 	return b.String()
 }
 
+// bannerLines renders the first diagnostics above the tasks, unless the
+// banner was dismissed.
+func (m taskModel) bannerLines() []string {
+	if m.dismissed || len(m.diagnostics) == 0 {
+		return nil
+	}
+	var lines []string
+	for i, diagnostic := range m.diagnostics {
+		if i == bannerLimit {
+			lines = append(lines, m.theme.Dim.Render(fmt.Sprintf("  ... %d more (Tab: details)", len(m.diagnostics)-bannerLimit)))
+			break
+		}
+		lines = append(lines, m.diagnosticStyle(diagnostic).Render("! "+diagnostic.String()))
+	}
+	return append(lines, m.theme.Dim.Render("  Ctrl+D: dismiss"))
+}
+
+// writeDetails renders the diagnostics panel, which replaces the task list.
+func (m taskModel) writeDetails(b *strings.Builder) {
+	b.WriteString(m.theme.Filter.Render(fmt.Sprintf("Diagnostics (%d)", len(m.diagnostics))))
+	b.WriteString("\n\n")
+	for _, diagnostic := range m.diagnostics {
+		b.WriteString(m.diagnosticStyle(diagnostic).Render(fmt.Sprintf("%s: %s", diagnostic.Severity, diagnostic.Message)))
+		b.WriteString("\n")
+		if diagnostic.Source != "" {
+			b.WriteString(m.theme.Dim.Render("  source: " + diagnostic.Source))
+			b.WriteString("\n")
+		}
+		var lines []string
+		if diagnostic.Err != nil {
+			lines = core.DetailLines(diagnostic.Err.Error())
+		}
+		for _, line := range append(lines, core.DetailLines(diagnostic.Detail)...) {
+			b.WriteString(m.theme.Dim.Render("  " + line))
+			b.WriteString("\n")
+		}
+	}
+	b.WriteString("\n")
+	b.WriteString(m.theme.Footer.Render("Esc/Tab: back  Ctrl+D: dismiss banner"))
+	b.WriteString("\n")
+}
+
+// writeTaskInfo renders the details of a task and its plugin, including
+// what the plugin's describe wrote on stderr.
+func (m taskModel) writeTaskInfo(b *strings.Builder, task core.TaskRecord) {
+	b.WriteString(m.theme.Filter.Render(fmt.Sprintf("%s (%s)", task.Task.Title, task.ID())))
+	b.WriteString("\n\n")
+	rows := [][2]string{
+		{"plugin", fmt.Sprintf("%s (%s, %s)", task.PluginTitle, task.PluginID, task.Scope)},
+		{"repo", task.RepoRoot},
+		{"path", task.PluginPath},
+		{"spec", task.SpecPath},
+		{"group", task.Task.Group},
+		{"description", task.Task.Description},
+	}
+	var inputs []string
+	for _, input := range task.Task.Inputs {
+		inputs = append(inputs, input.Name)
+	}
+	rows = append(rows, [2]string{"inputs", strings.Join(inputs, ", ")})
+	for _, row := range rows {
+		if row[1] == "" {
+			continue
+		}
+		b.WriteString(m.theme.Dim.Render(fmt.Sprintf("%-12s", row[0])) + row[1])
+		b.WriteString("\n")
+	}
+	if lines := core.DetailLines(task.DescribeStderr); len(lines) > 0 {
+		b.WriteString("\n")
+		b.WriteString(m.theme.Warning.Render("describe stderr:"))
+		b.WriteString("\n")
+		for _, line := range lines {
+			b.WriteString(m.theme.Dim.Render("  " + line))
+			b.WriteString("\n")
+		}
+	}
+	b.WriteString("\n")
+	b.WriteString(m.theme.Footer.Render("Esc/←: back"))
+	b.WriteString("\n")
+}
+
+func (m taskModel) diagnosticStyle(diagnostic core.Diagnostic) lipgloss.Style {
+	if diagnostic.Severity == core.SeverityError {
+		return m.theme.Error
+	}
+	return m.theme.Warning
+}
+
 func (m taskModel) filtered() []core.TaskRecord {
 	if strings.TrimSpace(m.filter) == "" {
 		return m.tasks
@@ -133,7 +280,7 @@ func (m taskModel) filtered() []core.TaskRecord {
 	var out []core.TaskRecord
 	for _, task := range m.tasks {
 		haystack := strings.ToLower(strings.Join([]string{
-			core.TaskID(task.PluginID, task.Task.Name),
+			task.ID(),
 			task.Task.Title,
 			task.Task.Description,
 			task.Task.Group,
@@ -163,7 +310,7 @@ func (m *taskModel) clampCursor() {
 
 func (m taskModel) maxRows() int {
 	if m.height > 0 {
-		rows := m.height - 6
+		rows := m.height - 6 - len(m.bannerLines())
 		if rows > 0 {
 			return rows
 		}
@@ -199,12 +346,12 @@ func (m taskModel) formatTaskLine(task core.TaskRecord) string {
 			m.theme.Group.Render("["+group+"]"),
 			title,
 			m.theme.Dim.Render(desc),
-			m.theme.Dim.Render("("+core.TaskID(task.PluginID, task.Task.Name)+")"),
+			m.theme.Dim.Render("("+task.ID()+")"),
 		)
 	}
 	return fmt.Sprintf("%s %s %s",
 		m.theme.Group.Render("["+group+"]"),
 		title,
-		m.theme.Dim.Render("("+core.TaskID(task.PluginID, task.Task.Name)+")"),
+		m.theme.Dim.Render("("+task.ID()+")"),
 	)
 }
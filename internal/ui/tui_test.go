@@ -0,0 +1,66 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ea2809/automate-me/internal/core"
+)
+
+func TestTaskModelDiagnostics(t *testing.T) {
+	var diagnostics core.Diagnostics
+	for _, name := range []string{"a", "b", "c", "d"} {
+		diagnostics.Error("/bin/"+name, "/bin/"+name+" describe failed", errors.New("exit status 1"))
+	}
+	model := taskModel{
+		tasks:       []core.TaskRecord{{PluginID: "p", Task: core.TaskSpec{Name: "t", Title: "T"}}},
+		diagnostics: diagnostics,
+		theme:       DefaultTheme(),
+	}
+	view := model.View()
+	if !strings.Contains(view, "/bin/c describe failed") || strings.Contains(view, "/bin/d describe failed") || !strings.Contains(view, "1 more") {
+		t.Fatalf("expected banner with 3 diagnostics, got:\n%s", view)
+	}
+
+	next, _ := model.Update(tea.KeyMsg{Type: tea.KeyTab})
+	model = next.(taskModel)
+	view = model.View()
+	if !model.details || !strings.Contains(view, "source: /bin/d") || strings.Contains(view, "[General]") {
+		t.Fatalf("expected details panel, got:\n%s", view)
+	}
+
+	next, _ = model.Update(tea.KeyMsg{Type: tea.KeyCtrlD})
+	model = next.(taskModel)
+	view = model.View()
+	if model.details || !model.dismissed || strings.Contains(view, "describe failed") || !strings.Contains(view, "[General]") {
+		t.Fatalf("expected dismissed banner, got:\n%s", view)
+	}
+}
+
+func TestTaskModelTaskInfo(t *testing.T) {
+	model := taskModel{
+		tasks: []core.TaskRecord{{
+			PluginID:       "p",
+			PluginTitle:    "Plugin",
+			PluginPath:     "/bin/p",
+			Scope:          core.ScopeGlobal,
+			Task:           core.TaskSpec{Name: "t", Title: "T", Inputs: []core.InputSpec{{Name: "env"}}},
+			DescribeStderr: "deprecated flag\n",
+		}},
+		theme: DefaultTheme(),
+	}
+	next, _ := model.Update(tea.KeyMsg{Type: tea.KeyRight})
+	model = next.(taskModel)
+	view := model.View()
+	for _, want := range []string{"T (p:t)", "/bin/p", "env", "describe stderr:", "deprecated flag"} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("expected %q in task details, got:\n%s", want, view)
+		}
+	}
+	next, _ = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if next.(taskModel).inspect {
+		t.Fatal("expected Esc to close the task details")
+	}
+}